@@ -0,0 +1,63 @@
+package forum
+
+import (
+	"errors"
+
+	"github.com/alijnmerchant21/forum-updated/model"
+	abci "github.com/cometbft/cometbft/abci/types"
+)
+
+// validateAdminTx checks the transactions only admins can send
+func validateAdminTx(store model.KVStore, tx *model.Tx) *txError {
+	sender, err := model.GetUser(store, tx.Sender)
+	if err != nil {
+		return newTxError(CodeTypeEncodingError, "Failed to load sender")
+	}
+	if !sender.Admin {
+		return newTxError(CodeTypeUnauthorized, "Only admins can send %s transactions", tx.Type)
+	}
+	switch tx.Type {
+	case model.TxTypeSetModerator:
+		set, err := tx.ParseSetModerator()
+		if err != nil {
+			return newTxError(CodeTypeInvalidTxFormat, "%v", err)
+		}
+		target, err := model.GetUser(store, set.User)
+		if errors.Is(err, model.ErrNotFound) || (err == nil && len(target.PubKey) == 0) {
+			return newTxError(CodeTypeUnknownUser, "User %s is not registered", set.User)
+		}
+		if err != nil {
+			return newTxError(CodeTypeEncodingError, "Failed to load user")
+		}
+	case model.TxTypeWordList:
+		if _, err := tx.ParseWordList(); err != nil {
+			return newTxError(CodeTypeInvalidTxFormat, "%v", err)
+		}
+	}
+	return nil
+}
+
+// deliverAdminTx executes a transaction validated by validateAdminTx
+func (app *ForumApp) deliverAdminTx(tx *model.Tx) ([]abci.Event, *txError) {
+	switch tx.Type {
+	case model.TxTypeSetModerator:
+		set, err := tx.ParseSetModerator()
+		if err != nil {
+			return nil, newTxError(CodeTypeInvalidTxFormat, "%v", err)
+		}
+		if err := app.updateUser(set.User, func(u *model.User) { u.Moderator = set.Moderator }); err != nil {
+			panic(err)
+		}
+		return []abci.Event{moderatorChangeEvent(tx.Sender, *set)}, nil
+	case model.TxTypeWordList:
+		update, err := tx.ParseWordList()
+		if err != nil {
+			return nil, newTxError(CodeTypeInvalidTxFormat, "%v", err)
+		}
+		if err := model.UpdateWordList(app.onGoingBlock, update.Add, update.Remove); err != nil {
+			panic(err)
+		}
+		return []abci.Event{wordListChangeEvent(tx.Sender, *update)}, nil
+	}
+	return nil, nil
+}
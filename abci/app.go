@@ -3,7 +3,6 @@ package forum
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"strings"
 
@@ -11,48 +10,89 @@ import (
 
 	abci "github.com/cometbft/cometbft/abci/types"
 	cryptoencoding "github.com/cometbft/cometbft/crypto/encoding"
+	cmtlog "github.com/cometbft/cometbft/libs/log"
 	cryptoproto "github.com/cometbft/cometbft/proto/tendermint/crypto"
+	cmtproto "github.com/cometbft/cometbft/proto/tendermint/types"
 
 	"github.com/cometbft/cometbft/version"
-	"github.com/dgraph-io/badger/v3"
 )
 
+// ApplicationVersion is the version of the application this binary
+// implements. The chain may run an older one until an upgrade moves it
+// forward, see upgrade.go.
 const ApplicationVersion = 1
 
 type ForumApp struct {
 	abci.BaseApplication
 	valAddrToPubKeyMap map[string]cryptoproto.PublicKey
 	CurseWords         string
-	state              AppState
-	onGoingBlock       *badger.Txn
+	// curse words reloaded during the current block
+	pendingWords *pendingWords
+	state        AppState
+	onGoingBlock *model.Cache
+	mempool      MempoolConfig
+	// number of transactions each sender has in the mempool
+	mempoolSenders map[string]int
+	history        HistoryConfig
+	retention      RetentionConfig
+	logger         cmtlog.Logger
 }
 
-func NewForumApp(dbDir string, appConfigPath string) (*ForumApp, error) {
-
-	db, err := model.NewDB(dbDir)
-	if err != nil {
-		fmt.Printf("Error initializing database: %s\n", err)
-		return nil, err
+// NewForumApp opens the application state at the db_dir of the
+// configuration, see LoadConfig. The app logs with the "forum" module and its
+// database with the "forum-db" module.
+func NewForumApp(cfg *Config, logger cmtlog.Logger) (*ForumApp, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
 	}
-	cfg, err := LoadConfig(appConfigPath)
+	db, err := model.OpenDB(cfg.DBBackend, cfg.DBPath())
 	if err != nil {
-		cfg = new(Config)
-		cfg.CurseWords = "bad"
+		return nil, fmt.Errorf("failed to open the %s database in %s: %w", cfg.DBBackend, cfg.DBPath(), err)
 	}
+	db.SetLogger(logger.With("module", "forum-db"))
 
-	cfg.CurseWords = DedupWords(cfg.CurseWords)
+	curseWords := DedupWords(cfg.CurseWords)
+
+	// A journal left in the DB means we crashed while writing the last block to disk.
+	// Finish writing it before loading the state, so that the height we report in Info
+	// matches the data we have and CometBFT replays the blocks after it during the handshake.
+	if _, err := db.RecoverJournal(); err != nil {
+		return nil, err
+	}
+	if err := migrateOnStartup(db, cfg.MigrateOnStartup); err != nil {
+		return nil, err
+	}
 
 	return &ForumApp{
 		state:              loadState(db),
 		valAddrToPubKeyMap: make(map[string]cryptoproto.PublicKey),
-		CurseWords:         cfg.CurseWords,
+		CurseWords:         curseWords,
+		pendingWords:       &pendingWords{},
+		mempool:            cfg.Mempool,
+		mempoolSenders:     make(map[string]int),
+		history:            cfg.History,
+		retention:          cfg.Retention,
+		logger:             logger.With("module", "forum"),
 	}, nil
 
 }
 
-// Return application info
-func (app ForumApp) Info(_ context.Context, info *abci.RequestInfo) (*abci.ResponseInfo, error) {
+// migrateOnStartup runs the pending migrations if the configuration asks for
+// it, or if the data can't be read without them.
+func migrateOnStartup(db *model.DB, configured bool) error {
+	pending, err := model.PendingMigrations(db)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 || !(configured || model.StartupRequired(pending)) {
+		return nil
+	}
+	_, err = db.Migrate()
+	return err
+}
 
+// Return application info
+func (app *ForumApp) Info(_ context.Context, info *abci.RequestInfo) (*abci.ResponseInfo, error) {
 	//Reading the validators from the DB because CometBFT expects the application to have them in memory
 	if len(app.valAddrToPubKeyMap) == 0 && app.state.Height > 0 {
 		validators := app.getValidators()
@@ -66,133 +106,149 @@ func (app ForumApp) Info(_ context.Context, info *abci.RequestInfo) (*abci.Respo
 	}
 	return &abci.ResponseInfo{
 		Version:         version.ABCIVersion,
-		AppVersion:      ApplicationVersion,
+		AppVersion:      app.state.AppVersion,
 		LastBlockHeight: app.state.Height,
 
 		LastBlockAppHash: app.state.Hash(),
 	}, nil
 }
 
-// Query blockchain
-func (app ForumApp) Query(ctx context.Context, query *abci.RequestQuery) (*abci.ResponseQuery, error) {
-	resp := abci.ResponseQuery{Key: query.Data}
-
-	// Parse sender from query data
-	sender := string(query.Data)
-
-	if sender == "history" {
-		messages, err := model.FetchHistory(app.state.DB)
-		if err != nil {
-			return nil, err
-		}
-		resp.Log = messages
-		resp.Value = []byte(messages)
-
-		return &resp, nil
-	}
-	// Retrieve all message sent by the sender
-	messages, err := model.GetMessagesBySender(app.state.DB, sender)
-	if err != nil {
-		return nil, err
-	}
+func (app *ForumApp) CheckTx(ctx context.Context, checktx *abci.RequestCheckTx) (*abci.ResponseCheckTx, error) {
+	// On recheck CometBFT asks us again about the transactions left in the mempool after a block.
+	// Anything that became invalid, e.g. because its sender was banned in that block, is evicted.
+	recheck := checktx.Type == abci.CheckTxType_Recheck
 
-	// Convert the messages to JSON and return as query result
-	resultBytes, err := json.Marshal(messages)
-	if err != nil {
-		return nil, err
+	if isBanTx(checktx.Tx) {
+		app.logger.Debug("rejected ban transaction submitted to the mempool")
+		return &abci.ResponseCheckTx{Code: CodeTypeInvalidTxFormat, Log: "Ban transactions can only be proposed by validators"}, nil
 	}
 
-	resp.Log = string(resultBytes)
-	resp.Value = resultBytes
-
-	return &resp, nil
-}
-
-func (app ForumApp) CheckTx(ctx context.Context, checktx *abci.RequestCheckTx) (*abci.ResponseCheckTx, error) {
 	// Parse the tx message
-	msg, err := model.ParseMessage(checktx.Tx)
+	tx, err := model.ParseTx(checktx.Tx)
 	if err != nil {
-		fmt.Printf("failed to parse transaction message checktx: %v\n", err)
+		if _, err := model.ParseMessage(checktx.Tx); err == nil {
+			app.logger.Debug("rejected unsigned post")
+			return &abci.ResponseCheckTx{Code: CodeTypeUnauthorized, Log: "Posts must be sent as signed post transactions"}, nil
+		}
+		app.logger.Debug("rejected malformed transaction", "err", err)
 		return &abci.ResponseCheckTx{Code: CodeTypeInvalidTxFormat, Log: "Invalid transaction format"}, nil
 	}
-	fmt.Println("Searching for sender ... ", msg.Sender)
-	u, err := app.state.DB.FindUserByName(msg.Sender)
-
-	if err != nil {
-		if !errors.Is(err, badger.ErrKeyNotFound) {
-			fmt.Println("problem in check tx: ", string(checktx.Tx))
-			return &abci.ResponseCheckTx{Code: CodeTypeEncodingError}, nil
-		}
-		fmt.Println("Not found user :", msg.Sender)
-	} else {
-		if u != nil && u.Banned {
-			return &abci.ResponseCheckTx{Code: CodeTypeBanned, Log: "User is banned"}, nil
+	sender := tx.Sender
+	logger := app.logger.With("sender", sender, "type", tx.Type, "nonce", tx.Nonce)
+	resp := app.checkTypedTx(tx)
+	if resp.Code != CodeTypeOK {
+		if recheck {
+			logger.Info("evicted transaction from the mempool", "code", resp.Code, "log", resp.Log)
+		} else {
+			logger.Debug("rejected transaction", "code", resp.Code, "log", resp.Log)
 		}
+		return resp, nil
+	}
+	if !app.trackSender(sender) {
+		logger.Debug("rejected transaction, too many in the mempool from its sender")
+		return &abci.ResponseCheckTx{
+			Code: CodeTypeSenderLimit,
+			Log:  fmt.Sprintf("Sender already has %d transactions in the mempool", app.mempool.MaxTxsPerSender),
+		}, nil
 	}
-	fmt.Println("Check tx success for ", msg.Message, " and ", msg.Sender)
-	return &abci.ResponseCheckTx{Code: CodeTypeOK}, nil
+	logger.Debug("accepted transaction", "recheck", recheck)
+	resp.GasWanted = int64(len(checktx.Tx))
+	return resp, nil
 }
 
 // Consensus Connection
 // Initialize blockchain w validators/other info from CometBFT
-func (app ForumApp) InitChain(_ context.Context, req *abci.RequestInitChain) (*abci.ResponseInitChain, error) {
+func (app *ForumApp) InitChain(_ context.Context, req *abci.RequestInitChain) (*abci.ResponseInitChain, error) {
+	genesis, err := ParseGenesisState(req.AppStateBytes)
+	if err != nil {
+		return nil, err
+	}
+	// The genesis state is written right away, so CheckTx sees it before the first block.
+	// If we crash before the first block is committed, CometBFT calls InitChain again
+	// and the same state is written once more.
+	store := app.state.DB.NewCache()
 	for _, v := range req.Validators {
-		app.updateValidator(v)
+		app.updateValidator(store, v)
+	}
+	if err := app.initGenesis(store, genesis); err != nil {
+		return nil, err
+	}
+	saveState(store, &app.state)
+	if err := store.Write(); err != nil {
+		panic(err)
 	}
 	appHash := app.state.Hash()
 
 	// This parameter can also be set in the genesis file
 	req.ConsensusParams.Abci.VoteExtensionsEnableHeight = 1
+	if req.ConsensusParams.Version == nil {
+		req.ConsensusParams.Version = &cmtproto.VersionParams{}
+	}
+	req.ConsensusParams.Version.App = app.state.AppVersion
 	return &abci.ResponseInitChain{ConsensusParams: req.ConsensusParams, AppHash: appHash}, nil
 }
 
 func (app *ForumApp) PrepareProposal(_ context.Context, proposal *abci.RequestPrepareProposal) (*abci.ResponsePrepareProposal, error) {
-	fmt.Println("entered prepareProp")
+	app.logger.Debug("preparing proposal", "height", proposal.Height, "txs", len(proposal.Txs))
 
 	voteExtensionCurseWords := app.getWordsFromVe(proposal.LocalLastCommit.Votes)
+	// The word list of the chain applies along with the words of the validators
+	wordList := app.wordList()
+	if wordList != "" {
+		voteExtensionCurseWords = DedupWords(strings.Trim(voteExtensionCurseWords+"|"+wordList, "|"))
+	}
 
 	// prepare proposal puts the BanTx first, then adds the other transactions
 	// ProcessProposal should verify this
 	proposedTxs := make([][]byte, 0)
 	finalProposal := make([][]byte, 0)
 	bannedUsersString := make(map[string]struct{})
+	budget := app.newPostBudget(proposal.Height)
 	for _, tx := range proposal.Txs {
-		msg, err := model.ParseMessage(tx)
+		typed, err := model.ParseTx(tx)
 		if err != nil {
 			continue
 		}
-		// Adding the curse words from vote extensions too
-		if !IsCurseWord(msg.Message, voteExtensionCurseWords) {
-			proposedTxs = append(proposedTxs, tx)
-		} else {
-			banTx := model.BanTx{UserName: msg.Sender}
-			bannedUsersString[msg.Sender] = struct{}{}
-			resultBytes, err := json.Marshal(banTx)
-			if err == nil {
-				finalProposal = append(finalProposal, resultBytes)
-			} else {
-				panic(fmt.Errorf("ban transaction failed to marshal in prepareProposal"))
+		// Posts, edits and profiles go through the word filter, adding the curse words from vote extensions too
+		if containsCurseWord(moderatedText(typed), voteExtensionCurseWords) {
+			app.logger.Info("proposing to ban user for a curse word", "height", proposal.Height, "sender", typed.Sender, "type", typed.Type)
+			bannedUsersString[typed.Sender] = struct{}{}
+			finalProposal = append(finalProposal, banTxBytes(typed.Sender))
+			continue
+		}
+		if typed.Type == model.TxTypePost {
+			// Posts over the rate limit would get our proposal rejected
+			allowed, err := budget.take(typed.Sender)
+			if err != nil {
+				panic(err)
+			}
+			if !allowed {
+				continue
 			}
 		}
+		proposedTxs = append(proposedTxs, tx)
 	}
 
 	// Need to loop again through the proposed Txs to make sure there is none left by a user that was banned after the tx was accepted
+	app.prioritize(proposedTxs)
 	for _, tx := range proposedTxs {
 		// there should be no error here as these are just transactions we have checked and added
-		msg, err := model.ParseMessage(tx)
+		typed, err := model.ParseTx(tx)
 		if err != nil {
 			panic(err)
 		}
-		if _, ok := bannedUsersString[msg.Sender]; !ok {
+		if _, ok := bannedUsersString[typed.Sender]; !ok {
 			finalProposal = append(finalProposal, tx)
 		}
 	}
-	return &abci.ResponsePrepareProposal{Txs: finalProposal}, nil
+	return &abci.ResponsePrepareProposal{Txs: limitTxBytes(finalProposal, proposal.MaxTxBytes)}, nil
 }
 
-func (ForumApp) ProcessProposal(_ context.Context, processproposal *abci.RequestProcessProposal) (*abci.ResponseProcessProposal, error) {
-	fmt.Println("entered processProp")
+func (app *ForumApp) ProcessProposal(_ context.Context, processproposal *abci.RequestProcessProposal) (*abci.ResponseProcessProposal, error) {
+	app.logger.Debug("processing proposal", "height", processproposal.Height, "txs", len(processproposal.Txs))
 	bannedUsers := make(map[string]struct{}, 0)
+	budget := app.newPostBudget(processproposal.Height)
+	wordList := app.wordList()
 
 	finishedBanTxIdx := len(processproposal.Txs)
 	for i, tx := range processproposal.Txs {
@@ -211,25 +267,64 @@ func (ForumApp) ProcessProposal(_ context.Context, processproposal *abci.Request
 
 	for _, tx := range processproposal.Txs[finishedBanTxIdx:] {
 		// From this point on, there should be no BanTxs anymore
-		// If there is one, ParseMessage will return an error as the
-		// format of the two transactions is different.
-		msg, err := model.ParseMessage(tx)
+		if isBanTx(tx) {
+			return &abci.ResponseProcessProposal{Status: abci.ResponseProcessProposal_REJECT}, nil
+		}
+		// Unsigned posts are not valid anymore
+		typed, err := model.ParseTx(tx)
 		if err != nil {
 			return &abci.ResponseProcessProposal{Status: abci.ResponseProcessProposal_REJECT}, nil
 		}
-		if _, ok := bannedUsers[msg.Sender]; ok {
+		if _, ok := bannedUsers[typed.Sender]; ok {
 			// sending us a tx from a banned user
 			return &abci.ResponseProcessProposal{Status: abci.ResponseProcessProposal_REJECT}, nil
 		}
+		if wordList != "" && containsCurseWord(moderatedText(typed), wordList) {
+			// the proposer should have banned the sender for using a word of the word list
+			return &abci.ResponseProcessProposal{Status: abci.ResponseProcessProposal_REJECT}, nil
+		}
+		if typed.Type == model.TxTypePost {
+			allowed, err := budget.take(typed.Sender)
+			if err != nil {
+				return nil, err
+			}
+			if !allowed {
+				// the proposer let a user post more than the rate limit allows
+				return &abci.ResponseProcessProposal{Status: abci.ResponseProcessProposal_REJECT}, nil
+			}
+		}
 	}
 	return &abci.ResponseProcessProposal{Status: abci.ResponseProcessProposal_ACCEPT}, nil
 }
 
 // Deliver the decided block with its txs to the Application
 func (app *ForumApp) FinalizeBlock(_ context.Context, req *abci.RequestFinalizeBlock) (*abci.ResponseFinalizeBlock, error) {
-	fmt.Println("entered finalizeBlock")
+	app.logger.Debug("finalizing block", "height", req.Height, "txs", len(req.Txs))
+	// All writes of the block are staged in a cache, which later transactions
+	// read through. Nothing is written to disk before Commit.
+	app.onGoingBlock = app.state.DB.NewCache()
+	// An upgrade scheduled at this height runs first, or stops the node if it
+	// needs a newer binary
+	var events []abci.Event
+	upgradeEvent, paramUpdates, err := app.applyUpgrade(req.Height)
+	if err != nil {
+		return nil, err
+	}
+	if upgradeEvent != nil {
+		events = append(events, *upgradeEvent)
+	}
+	// Migrations scheduled at this height run before the transactions, which
+	// expect the new layout
+	if req.Height == app.state.Params.MigrationHeight {
+		applied, err := model.Migrate(app.onGoingBlock)
+		if err != nil {
+			panic(err)
+		}
+		for _, m := range applied {
+			app.logger.Info("migrated data", "height", req.Height, "version", m.Version, "description", m.Description)
+		}
+	}
 	// Iterate over Tx in current block
-	app.onGoingBlock = app.state.DB.GetDB().NewTransaction(true)
 	respTxs := make([]*abci.ExecTxResult, len(req.Txs))
 	finishedBanTxIdx := len(req.Txs)
 	for i, tx := range req.Txs {
@@ -241,11 +336,12 @@ func (app *ForumApp) FinalizeBlock(_ context.Context, req *abci.RequestFinalizeB
 			if err != nil {
 				respTxs[i] = &abci.ExecTxResult{Code: CodeTypeEncodingError}
 			} else {
-				err := UpdateOrSetUser(app.state.DB, banTx.UserName, true, app.onGoingBlock)
+				err := UpdateOrSetUser(app.onGoingBlock, banTx.UserName, true)
 				if err != nil {
 					panic(err)
 				}
-				respTxs[i] = &abci.ExecTxResult{Code: CodeTypeOK}
+				app.logger.Info("banned user", "height", req.Height, "index", i, "user", banTx.UserName, "reason", banTx.Reason)
+				respTxs[i] = &abci.ExecTxResult{Code: CodeTypeOK, Events: []abci.Event{banEvent(*banTx)}}
 			}
 		} else {
 			finishedBanTxIdx = i
@@ -255,38 +351,18 @@ func (app *ForumApp) FinalizeBlock(_ context.Context, req *abci.RequestFinalizeB
 
 	for idx, tx := range req.Txs[finishedBanTxIdx:] {
 		// From this point on, there should be no BanTxs anymore
-		// If there is one, ParseMessage will return an error as the
-		// format of the two transactions is different.
-		msg, err := model.ParseMessage(tx)
-		i := idx + finishedBanTxIdx
-		if err != nil {
-			respTxs[i] = &abci.ExecTxResult{Code: CodeTypeEncodingError}
-		} else {
-			// Check if this sender already existed; if not, add the user too
-			err := UpdateOrSetUser(app.state.DB, msg.Sender, false, app.onGoingBlock)
-			if err != nil {
-				panic(err)
-			}
-			// Add the message for this sender
-			message, err := model.AppendToExistingMsgs(app.state.DB, *msg)
-			if err != nil {
-				panic(err)
-			}
-			app.onGoingBlock.Set([]byte(msg.Sender+"msg"), []byte(message))
-			chatHistory, err := model.AppendToChat(app.state.DB, *msg)
-			if err != nil {
-				panic(err)
-			}
-			// Append messages to chat history
-			app.onGoingBlock.Set([]byte("history"), []byte(chatHistory))
-			// This adds the user to the DB, but the data is not committed nor persisted until Comit is called
-			respTxs[i] = &abci.ExecTxResult{Code: abci.CodeTypeOK}
-			app.state.Size++
-		}
+		respTxs[idx+finishedBanTxIdx] = app.deliverTx(tx, req.Height, idx+finishedBanTxIdx)
 	}
+	events = append(events, app.completeRecoveries(req.Height)...)
+	app.expireUpgradeProposals(req.Height)
 	app.state.Height = req.Height
 
-	response := &abci.ResponseFinalizeBlock{TxResults: respTxs, AppHash: app.state.Hash()}
+	response := &abci.ResponseFinalizeBlock{
+		TxResults:             respTxs,
+		Events:                events,
+		ConsensusParamUpdates: paramUpdates,
+		AppHash:               app.state.Hash(),
+	}
 	return response, nil
 }
 
@@ -294,12 +370,19 @@ func (app *ForumApp) FinalizeBlock(_ context.Context, req *abci.RequestFinalizeB
 // Here we actually write the staged transactions into the database.
 // For details on why it has to be done here, check the Crash recovery section
 // of the ABCI spec
-func (app ForumApp) Commit(_ context.Context, commit *abci.RequestCommit) (*abci.ResponseCommit, error) {
-	if err := app.onGoingBlock.Commit(); err != nil {
+// The app state is written together with the block, so the height stored on disk
+// always matches the data.
+func (app *ForumApp) Commit(_ context.Context, commit *abci.RequestCommit) (*abci.ResponseCommit, error) {
+	saveState(app.onGoingBlock, &app.state)
+	retainHeight := app.retention.retainHeight(app.state.Height)
+	if err := app.commitBlock(app.state.Height, retainHeight); err != nil {
 		panic(err)
 	}
-	saveState(&app.state)
-	return &abci.ResponseCommit{}, nil
+	// The mempool is rechecked after the commit, which counts the remaining transactions again
+	app.mempoolSenders = make(map[string]int)
+	app.logger.Debug("committed block", "height", app.state.Height, "app_hash", fmt.Sprintf("%X", app.state.Hash()), "retain_height", retainHeight)
+	app.applyPendingWords()
+	return &abci.ResponseCommit{RetainHeight: retainHeight}, nil
 }
 
 // State Sync Connection
@@ -321,13 +404,14 @@ func (ForumApp) ApplySnapshotChunk(_ context.Context, applysnapshotchunk *abci.R
 }
 
 func (app ForumApp) ExtendVote(_ context.Context, extendvote *abci.RequestExtendVote) (*abci.ResponseExtendVote, error) {
-	fmt.Println("Entered extend vote")
+	app.logger.Debug("extending vote", "height", extendvote.Height, "curse_words", app.CurseWords)
 
 	return &abci.ResponseExtendVote{VoteExtension: []byte(app.CurseWords)}, nil
 }
 
 func (app ForumApp) VerifyVoteExtension(_ context.Context, req *abci.RequestVerifyVoteExtension) (*abci.ResponseVerifyVoteExtension, error) {
-	fmt.Println("Entered verify extension") // Will not be called for extensions generated by this validator
+	// Will not be called for extensions generated by this validator
+	app.logger.Debug("verifying vote extension", "height", req.Height, "validator", fmt.Sprintf("%X", req.ValidatorAddress))
 	if _, ok := app.valAddrToPubKeyMap[string(req.ValidatorAddress)]; !ok {
 		// we do not have a validator with this address mapped; this should never happen
 		panic(fmt.Errorf("unknown validator"))
@@ -362,7 +446,7 @@ func (app *ForumApp) getWordsFromVe(voteExtensions []abci.ExtendedVoteInfo) stri
 		}
 
 	}
-	fmt.Println("Processed vote extensions :", curseWordMap)
+	app.logger.Debug("processed vote extensions", "votes", len(voteExtensions), "words", len(curseWordMap))
 	majority := len(app.valAddrToPubKeyMap) / 3 // We define the majority to be at least 1/3 of the validators;
 
 	voteExtensionCurseWords := ""
@@ -378,3 +462,8 @@ func (app *ForumApp) getWordsFromVe(voteExtensions []abci.ExtendedVoteInfo) stri
 	return voteExtensionCurseWords
 
 }
+
+// Close closes the database of the application
+func (app *ForumApp) Close() error {
+	return app.state.DB.Close()
+}
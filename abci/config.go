@@ -3,36 +3,167 @@ package forum
 import (
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/BurntSushi/toml"
+	cmtflags "github.com/cometbft/cometbft/libs/cli/flags"
+	cmtlog "github.com/cometbft/cometbft/libs/log"
+
+	"github.com/alijnmerchant21/forum-updated/model"
+)
+
+// Log formats of the application
+const (
+	LogFormatPlain = "plain"
+	LogFormatJSON  = "json"
 )
 
+// defaultLogLevel applies to the modules log_level doesn't mention
+const defaultLogLevel = "info"
+
+// EnvPrefix starts the names of the environment variables overriding the
+// configuration, e.g. FORUM_DB_BACKEND or FORUM_MEMPOOL_PRIORITY_POLICY
+const EnvPrefix = "FORUM"
+
 type Config struct {
-	ChainID    string `toml:"chain_id"`
+	// RootDir is the home directory of the node; relative paths of the
+	// configuration are resolved from it
+	RootDir string `toml:"-"`
+
+	ChainID string `toml:"chain_id"`
+	// CurseWords are the curse words of this validator, '|' separated
 	CurseWords string `toml:"curse_words"`
+	// DBDir is the directory of the application state, relative to RootDir
+	// unless it is absolute
+	DBDir string `toml:"db_dir"`
+	// DBBackend is the storage engine of the application state: "badger",
+	// "goleveldb", "memory", or another backend of cometbft-db
+	DBBackend string        `toml:"db_backend"`
+	Mempool   MempoolConfig `toml:"mempool"`
+	// MigrateOnStartup runs the pending data migrations when the node starts.
+	// When it is off, they wait for the migration_height consensus parameter.
+	MigrateOnStartup bool `toml:"migrate_on_startup"`
+	// History sets how much of the state at past heights is kept for queries
+	History HistoryConfig `toml:"history"`
+	// Retention sets which blocks CometBFT keeps
+	Retention RetentionConfig `toml:"retention"`
+	// HTTPAddress is the address of the HTTP API of the node; empty disables it
+	HTTPAddress string `toml:"http_address"`
+	// LogLevel is the level of the application logs, e.g. "info" or
+	// "forum:debug,*:error"
+	LogLevel string `toml:"log_level"`
+	// LogFormat is "plain" or "json"
+	LogFormat string `toml:"log_format"`
 }
 
-func LoadConfig(file string) (*Config, error) {
-	cfg := &Config{
+// MempoolConfig holds the local mempool policy of this node. It only affects
+// which transactions this node accepts and how it orders them in its own
+// proposals, so validators may configure it differently.
+type MempoolConfig struct {
+	// PriorityPolicy decides which transactions go first in our proposals;
+	// one of "fifo", "moderator" or "fee"
+	PriorityPolicy string `toml:"priority_policy"`
+	// MaxTxsPerSender limits the transactions a single sender can have in the
+	// mempool; 0 means no limit
+	MaxTxsPerSender int `toml:"max_txs_per_sender"`
+}
+
+func DefaultConfig() *Config {
+	return &Config{
 		ChainID:    "forum_chain",
 		CurseWords: "bad|apple|muggles",
+		DBDir:      filepath.Join("data", "forum-db"),
+		DBBackend:  model.BackendBadger,
+		Mempool: MempoolConfig{
+			PriorityPolicy:  PriorityPolicyFIFO,
+			MaxTxsPerSender: 0,
+		},
+		MigrateOnStartup: true,
+		History: HistoryConfig{
+			Pruning: PruningDefault,
+		},
+		HTTPAddress: "127.0.0.1:8080",
+		LogLevel:    defaultLogLevel,
+		LogFormat:   LogFormatPlain,
+	}
+}
+
+// SetRoot sets the home directory the paths of the configuration are relative to
+func (cfg *Config) SetRoot(root string) *Config {
+	cfg.RootDir = root
+	return cfg
+}
+
+// DBPath returns the directory of the application state
+func (cfg Config) DBPath() string {
+	if filepath.IsAbs(cfg.DBDir) {
+		return cfg.DBDir
 	}
-	_, err := toml.DecodeFile(file, &cfg)
+	return filepath.Join(cfg.RootDir, cfg.DBDir)
+}
+
+// LoadConfig reads the configuration in file and applies the overrides of
+// the environment to it. Unknown parameters and invalid values are errors.
+func LoadConfig(file string) (*Config, error) {
+	cfg := DefaultConfig()
+	md, err := toml.DecodeFile(file, &cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load config from %q: %w", file, err)
 	}
-	return cfg, cfg.Validate()
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return nil, fmt.Errorf("unknown parameter %q in config %q", undecoded[0].String(), file)
+	}
+	if err := applyEnv(cfg, EnvPrefix, os.LookupEnv); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %q: %w", file, err)
+	}
+	return cfg, nil
 }
 
-// Validate validates the configuration. We don't do exhaustive config
-// validation here, instead relying on Testnet.Validate() to handle it.
-//
-//nolint:goconst
+// Validate checks every parameter of the configuration: the required
+// settings are set, the mempool policy, log format and log level are known,
+// the curse words are valid, and so are the history and retention sections.
+// Unknown keys are rejected earlier, when the file is decoded.
 func (cfg Config) Validate() error {
 	switch {
 	case cfg.ChainID == "":
 		return errors.New("chain_id parameter is required")
-	default:
+	case cfg.DBDir == "":
+		return errors.New("db_dir parameter is required")
+	case cfg.DBBackend == "":
+		return errors.New("db_backend parameter is required")
+	case !isPriorityPolicy(cfg.Mempool.PriorityPolicy):
+		return fmt.Errorf("unknown mempool priority_policy %q", cfg.Mempool.PriorityPolicy)
+	case cfg.Mempool.MaxTxsPerSender < 0:
+		return errors.New("mempool max_txs_per_sender can't be negative")
+	case cfg.LogFormat != LogFormatPlain && cfg.LogFormat != LogFormatJSON:
+		return fmt.Errorf("unknown log_format %q, expected %q or %q", cfg.LogFormat, LogFormatPlain, LogFormatJSON)
+	}
+	if _, err := cmtflags.ParseLogLevel(cfg.LogLevel, cmtlog.NewNopLogger(), defaultLogLevel); err != nil {
+		return fmt.Errorf("invalid log_level: %w", err)
+	}
+	if err := ValidateCurseWords(cfg.CurseWords); err != nil {
+		return fmt.Errorf("invalid curse_words: %w", err)
+	}
+	if err := cfg.History.Validate(); err != nil {
+		return err
+	}
+	return cfg.Retention.Validate()
+}
+
+// ValidateCurseWords checks a '|' separated list of curse words; it may be empty
+func ValidateCurseWords(curseWords string) error {
+	if curseWords == "" {
 		return nil
 	}
+	for _, word := range strings.Split(curseWords, "|") {
+		if err := model.ValidateWord(word); err != nil {
+			return fmt.Errorf("%q: %w", word, err)
+		}
+	}
+	return nil
 }
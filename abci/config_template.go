@@ -0,0 +1,82 @@
+package forum
+
+import (
+	"bytes"
+	"os"
+	"text/template"
+)
+
+const configTemplate = `# Configuration of the forum application
+#
+# Every parameter can be overridden with an environment variable named after
+# it in upper case and prefixed with FORUM_ and its section, e.g.
+# FORUM_DB_BACKEND or FORUM_MEMPOOL_PRIORITY_POLICY.
+
+# Chain the node runs
+chain_id = "{{ .ChainID }}"
+
+# Curse words of this validator, '|' separated. They are shared with the other
+# validators through vote extensions. Words have 1 to 64 characters.
+curse_words = "{{ .CurseWords }}"
+
+# Directory of the application state, relative to the home directory unless
+# it is absolute
+db_dir = "{{ .DBDir }}"
+
+# Storage engine of the application state: "badger", "goleveldb", "memory",
+# or another backend cometbft-db was built with
+db_backend = "{{ .DBBackend }}"
+
+# Run the pending data migrations when the node starts. When false, they run
+# at the migration_height consensus parameter instead.
+migrate_on_startup = {{ .MigrateOnStartup }}
+
+# Address of the HTTP API of the node (empty to disable it)
+http_address = "{{ .HTTPAddress }}"
+
+# Level of the application logs: "debug", "info", "error" or "none", or a
+# level per module such as "forum:debug,*:error"
+log_level = "{{ .LogLevel }}"
+
+# Format of the application logs: "plain" or "json"
+log_format = "{{ .LogFormat }}"
+
+[mempool]
+# Order of the transactions in our proposals: "fifo", "moderator" or "fee"
+priority_policy = "{{ .Mempool.PriorityPolicy }}"
+# Maximum number of transactions a sender can have in the mempool (0 = no limit).
+# The limit needs mempool.recheck = true in config.toml.
+max_txs_per_sender = {{ .Mempool.MaxTxsPerSender }}
+
+[history]
+# How much of the state at past heights is kept, for queries at a height:
+# "default" keeps the last 362880 heights, "nothing" prunes nothing,
+# "everything" keeps no history and "custom" uses the settings below
+pruning = "{{ .History.Pruning }}"
+# Number of recent heights kept with the "custom" strategy
+keep_recent = {{ .History.KeepRecent }}
+# Number of blocks between two prunings with the "custom" strategy
+interval = {{ .History.Interval }}
+
+[retention]
+# Number of recent blocks CometBFT keeps; older ones are pruned through the
+# RetainHeight of Commit, along with the history of the state (0 = keep all)
+keep_recent = {{ .Retention.KeepRecent }}
+# Prune blocks keep_every at a time, so the oldest block kept is at a
+# multiple of keep_every (0 = disabled)
+keep_every = {{ .Retention.KeepEvery }}
+# Blocks between two state sync snapshots; blocks are never pruned below the
+# latest one (0 = no snapshots)
+snapshot_interval = {{ .Retention.SnapshotInterval }}
+`
+
+var configTmpl = template.Must(template.New("app.toml").Parse(configTemplate))
+
+// WriteConfigFile writes the configuration as a commented app.toml
+func WriteConfigFile(path string, cfg *Config) error {
+	var buf bytes.Buffer
+	if err := configTmpl.Execute(&buf, cfg); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
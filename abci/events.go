@@ -0,0 +1,220 @@
+package forum
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/alijnmerchant21/forum-updated/model"
+	abci "github.com/cometbft/cometbft/abci/types"
+)
+
+// Event types emitted in the results of FinalizeBlock. CometBFT indexes the
+// attributes marked for indexing, so they can be searched with /tx_search,
+// e.g. "post.sender='alice'", or subscribed to over the websocket.
+const (
+	EventTypePost            = "post"
+	EventTypeBan             = "ban"
+	EventTypeUnban           = "unban"
+	EventTypeEdit            = "edit"
+	EventTypeDelete          = "delete"
+	EventTypeModeratorChange = "moderator_change"
+	EventTypeWordListChange  = "word_list_change"
+	EventTypeTransfer        = "transfer"
+	EventTypeRegister        = "register"
+	EventTypeProfile         = "profile"
+	EventTypeKeyChange       = "key_change"
+	EventTypeSetRecovery     = "set_recovery"
+	EventTypeRecoveryRequest = "recovery_request"
+	EventTypeRecoveryCancel  = "recovery_cancel"
+	EventTypeFlag            = "flag"
+	EventTypeAppeal          = "appeal"
+	EventTypeAppealResolved  = "appeal_resolved"
+	// Upgrades
+	EventTypeUpgradeProposal  = "upgrade_proposal"
+	EventTypeUpgradeApproval  = "upgrade_approval"
+	EventTypeUpgradeScheduled = "upgrade_scheduled"
+	EventTypeUpgrade          = "upgrade"
+)
+
+// Event attribute keys
+const (
+	AttributeKeySender    = "sender"
+	AttributeKeyUser      = "user"
+	AttributeKeyMessageID = "message_id"
+	AttributeKeyBoard     = "board"
+	AttributeKeyReason    = "reason"
+	AttributeKeyRecipient = "recipient"
+	AttributeKeyAmount    = "amount"
+	AttributeKeyPubKey    = "pub_key"
+	AttributeKeyModerator = "moderator"
+	AttributeKeyAdded     = "added"
+	AttributeKeyRemoved   = "removed"
+	AttributeKeyProposal  = "proposal_id"
+	AttributeKeyName      = "name"
+	AttributeKeyVersion   = "version"
+	AttributeKeyHeight    = "height"
+	AttributeKeyReplyTo   = "reply_to"
+	AttributeKeyAccepted  = "accepted"
+)
+
+// Reasons given for bans issued by the application
+const (
+	BanReasonCurseWord = "curse_word"
+)
+
+func attribute(key, value string) abci.EventAttribute {
+	return abci.EventAttribute{Key: key, Value: value, Index: true}
+}
+
+func postEvent(msg model.Message) abci.Event {
+	event := abci.Event{
+		Type: EventTypePost,
+		Attributes: []abci.EventAttribute{
+			attribute(AttributeKeySender, msg.Sender),
+			attribute(AttributeKeyMessageID, strconv.FormatUint(msg.ID, 10)),
+			attribute(AttributeKeyBoard, msg.Board),
+		},
+	}
+	if msg.ReplyTo != 0 {
+		event.Attributes = append(event.Attributes, attribute(AttributeKeyReplyTo, strconv.FormatUint(msg.ReplyTo, 10)))
+	}
+	return event
+}
+
+func messageEvent(eventType string, sender string, id uint64) abci.Event {
+	return abci.Event{
+		Type: eventType,
+		Attributes: []abci.EventAttribute{
+			attribute(AttributeKeySender, sender),
+			attribute(AttributeKeyMessageID, strconv.FormatUint(id, 10)),
+		},
+	}
+}
+
+func banEvent(ban model.BanTx) abci.Event {
+	return abci.Event{
+		Type: EventTypeBan,
+		Attributes: []abci.EventAttribute{
+			attribute(AttributeKeyUser, ban.UserName),
+			attribute(AttributeKeyReason, ban.Reason),
+		},
+	}
+}
+
+func transferEvent(sender string, transfer model.TransferTx) abci.Event {
+	return abci.Event{
+		Type: EventTypeTransfer,
+		Attributes: []abci.EventAttribute{
+			attribute(AttributeKeySender, sender),
+			attribute(AttributeKeyRecipient, transfer.To),
+			attribute(AttributeKeyAmount, strconv.FormatUint(transfer.Amount, 10)),
+		},
+	}
+}
+
+func registerEvent(u *model.User) abci.Event {
+	return abci.Event{
+		Type: EventTypeRegister,
+		Attributes: []abci.EventAttribute{
+			attribute(AttributeKeyUser, u.Name),
+			attribute(AttributeKeyPubKey, base64.StdEncoding.EncodeToString(u.PubKey)),
+		},
+	}
+}
+
+func keyChangeEvent(u *model.User, reason string) abci.Event {
+	return abci.Event{
+		Type: EventTypeKeyChange,
+		Attributes: []abci.EventAttribute{
+			attribute(AttributeKeyUser, u.Name),
+			attribute(AttributeKeyPubKey, base64.StdEncoding.EncodeToString(u.PubKey)),
+			attribute(AttributeKeyReason, reason),
+		},
+	}
+}
+
+func userEvent(eventType string, name string) abci.Event {
+	return abci.Event{
+		Type:       eventType,
+		Attributes: []abci.EventAttribute{attribute(AttributeKeyUser, name)},
+	}
+}
+
+func recoveryRequestEvent(sender string, recover model.RecoverTx) abci.Event {
+	return abci.Event{
+		Type: EventTypeRecoveryRequest,
+		Attributes: []abci.EventAttribute{
+			attribute(AttributeKeyUser, recover.User),
+			attribute(AttributeKeySender, sender),
+			attribute(AttributeKeyPubKey, base64.StdEncoding.EncodeToString(recover.PubKey)),
+		},
+	}
+}
+
+func moderatorChangeEvent(sender string, set model.SetModeratorTx) abci.Event {
+	return abci.Event{
+		Type: EventTypeModeratorChange,
+		Attributes: []abci.EventAttribute{
+			attribute(AttributeKeyUser, set.User),
+			attribute(AttributeKeySender, sender),
+			attribute(AttributeKeyModerator, strconv.FormatBool(set.Moderator)),
+		},
+	}
+}
+
+func wordListChangeEvent(sender string, update model.WordListTx) abci.Event {
+	return abci.Event{
+		Type: EventTypeWordListChange,
+		Attributes: []abci.EventAttribute{
+			attribute(AttributeKeySender, sender),
+			attribute(AttributeKeyAdded, strings.Join(update.Add, "|")),
+			attribute(AttributeKeyRemoved, strings.Join(update.Remove, "|")),
+		},
+	}
+}
+
+func upgradeEvent(eventType string, sender string, p model.UpgradeProposal) abci.Event {
+	return abci.Event{
+		Type: eventType,
+		Attributes: []abci.EventAttribute{
+			attribute(AttributeKeyProposal, strconv.FormatUint(p.ID, 10)),
+			attribute(AttributeKeySender, sender),
+			attribute(AttributeKeyName, p.Plan.Name),
+			attribute(AttributeKeyVersion, strconv.FormatUint(p.Plan.Version, 10)),
+			attribute(AttributeKeyHeight, strconv.FormatInt(p.Plan.Height, 10)),
+		},
+	}
+}
+
+func flagEvent(sender string, flag model.FlagTx) abci.Event {
+	return abci.Event{
+		Type: EventTypeFlag,
+		Attributes: []abci.EventAttribute{
+			attribute(AttributeKeySender, sender),
+			attribute(AttributeKeyMessageID, strconv.FormatUint(flag.MessageID, 10)),
+			attribute(AttributeKeyReason, flag.Reason),
+		},
+	}
+}
+
+func appealEvent(sender string, appeal model.AppealTx) abci.Event {
+	return abci.Event{
+		Type: EventTypeAppeal,
+		Attributes: []abci.EventAttribute{
+			attribute(AttributeKeyUser, sender),
+			attribute(AttributeKeyReason, appeal.Reason),
+		},
+	}
+}
+
+func appealResolvedEvent(sender string, resolve model.ResolveAppealTx) abci.Event {
+	return abci.Event{
+		Type: EventTypeAppealResolved,
+		Attributes: []abci.EventAttribute{
+			attribute(AttributeKeyUser, resolve.User),
+			attribute(AttributeKeySender, sender),
+			attribute(AttributeKeyAccepted, strconv.FormatBool(resolve.Accept)),
+		},
+	}
+}
@@ -0,0 +1,158 @@
+package forum
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alijnmerchant21/forum-updated/bank"
+	"github.com/alijnmerchant21/forum-updated/model"
+)
+
+// ExportGenesis reads the content of the forum from a store, at the latest
+// height or at a past one (see model.DB.AtHeight), as a genesis app_state.
+// A new chain started from it has the same users, messages, bans, roles,
+// balances, flags, appeals and parameters. What only makes sense at the
+// heights of the old chain is left out: pending recoveries, the recent posts
+// counted by the rate limit, a scheduled migration height, and the heights of
+// flags and appeals. The state must be at the latest schema version.
+func ExportGenesis(store model.KVStore) (*GenesisState, error) {
+	pending, err := model.PendingMigrations(store)
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) > 0 {
+		return nil, fmt.Errorf("the state needs the migrations to schema version %d before it can be exported", pending[len(pending)-1].Version)
+	}
+	state, err := readAppState(store)
+	if err != nil {
+		return nil, err
+	}
+	params := state.Params
+	params.MigrationHeight = 0
+	genesis := &GenesisState{Params: &params}
+
+	err = store.Iterate([]byte(model.PrefixUser), func(_, value []byte) error {
+		var u model.User
+		if err := json.Unmarshal(value, &u); err != nil {
+			return err
+		}
+		if u.Banned {
+			genesis.Bans = append(genesis.Bans, u.Name)
+		}
+		// Names banned before they registered have no account
+		if len(u.PubKey) == 0 {
+			return nil
+		}
+		stats := u.Stats()
+		genesis.Users = append(genesis.Users, GenesisUser{
+			Name:        u.Name,
+			PubKey:      u.PubKey,
+			RecoveryKey: u.RecoveryKey,
+			Nonce:       u.Nonce,
+			KeyHistory:  u.KeyHistory,
+			Stats:       &stats,
+		})
+		if u.Moderator {
+			genesis.Moderators = append(genesis.Moderators, u.Name)
+		}
+		if u.Admin {
+			genesis.Admins = append(genesis.Admins, u.Name)
+		}
+		if u.Appeal != nil {
+			genesis.Appeals = append(genesis.Appeals, GenesisAppeal{User: u.Name, Reason: u.Appeal.Reason})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = store.Iterate([]byte(model.PrefixProfile), func(key, value []byte) error {
+		var profile model.Profile
+		if err := json.Unmarshal(value, &profile); err != nil {
+			return err
+		}
+		user := strings.TrimPrefix(string(key), model.PrefixProfile)
+		genesis.Profiles = append(genesis.Profiles, GenesisProfile{User: user, Profile: profile})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = store.Iterate([]byte(model.PrefixMessages), func(key, _ []byte) error {
+		messages, err := model.GetMessagesBySender(store, strings.TrimPrefix(string(key), model.PrefixMessages))
+		if err != nil {
+			return err
+		}
+		genesis.Messages = append(genesis.Messages, messages...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(genesis.Messages, func(i, j int) bool {
+		return genesis.Messages[i].ID < genesis.Messages[j].ID
+	})
+	if genesis.MessageCount, err = model.MessageCount(store); err != nil {
+		return nil, err
+	}
+	if genesis.History, err = model.FetchHistory(store); err != nil {
+		return nil, err
+	}
+
+	err = store.Iterate([]byte(model.PrefixFlag), func(key, _ []byte) error {
+		id, err := strconv.ParseUint(strings.TrimPrefix(string(key), model.PrefixFlag), 10, 64)
+		if err != nil {
+			return err
+		}
+		flags, err := model.GetFlags(store, id)
+		if err != nil {
+			return err
+		}
+		for _, f := range flags {
+			genesis.Flags = append(genesis.Flags, GenesisFlag{MessageID: id, User: f.User, Reason: f.Reason})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(genesis.Flags, func(i, j int) bool {
+		return genesis.Flags[i].MessageID < genesis.Flags[j].MessageID
+	})
+
+	err = store.Iterate([]byte(model.PrefixBalance), func(key, _ []byte) error {
+		user := strings.TrimPrefix(string(key), model.PrefixBalance)
+		amount, err := bank.GetBalance(store, user)
+		if err != nil || amount == 0 {
+			return err
+		}
+		genesis.Balances = append(genesis.Balances, GenesisBalance{User: user, Amount: amount})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if genesis.WordList, err = model.GetWordList(store); err != nil {
+		return nil, err
+	}
+	if genesis.Boards, err = model.GetBoards(store); err != nil {
+		return nil, err
+	}
+	return genesis, genesis.Validate()
+}
+
+// Export returns the state of the running application at a height, 0 for
+// the latest one, as a genesis app_state
+func (app *ForumApp) Export(height int64) (*GenesisState, error) {
+	store, err := app.stateAt(height)
+	if err != nil {
+		return nil, err
+	}
+	return ExportGenesis(store)
+}
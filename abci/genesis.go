@@ -0,0 +1,333 @@
+package forum
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/alijnmerchant21/forum-updated/bank"
+	"github.com/alijnmerchant21/forum-updated/model"
+	"github.com/cometbft/cometbft/crypto/ed25519"
+)
+
+// GenesisState is the app_state of the genesis file. Every field is optional.
+type GenesisState struct {
+	// Params are the consensus parameters; missing ones keep their default
+	Params *Params `json:"params,omitempty"`
+	// Users are the accounts registered at genesis
+	Users []GenesisUser `json:"users,omitempty"`
+	// Moderators and Admins name genesis users that get these roles
+	Moderators []string `json:"moderators,omitempty"`
+	Admins     []string `json:"admins,omitempty"`
+	// WordList is the initial word list of the chain, which admins change later
+	WordList []string `json:"word_list,omitempty"`
+	// Boards are the boards messages can be posted to; if there are none,
+	// any board can be used
+	Boards   []model.Board    `json:"boards,omitempty"`
+	Balances []GenesisBalance `json:"balances,omitempty"`
+
+	// The fields below carry the content of a chain exported with
+	// ExportGenesis, to restart it as a new chain.
+
+	// Bans names the banned users. Names that aren't genesis users are
+	// reserved, so they can't be registered.
+	Bans     []string         `json:"bans,omitempty"`
+	Profiles []GenesisProfile `json:"profiles,omitempty"`
+	// Messages keep their IDs; MessageCount is the number of IDs given out,
+	// so new messages don't reuse the ID of a deleted one
+	Messages     []model.Message `json:"messages,omitempty"`
+	MessageCount uint64          `json:"message_count,omitempty"`
+	// History is the chat history
+	History string `json:"history,omitempty"`
+	// Flags are the reports of messages, and Appeals the appeals of banned
+	// users waiting for a moderator; both are imported at height 0
+	Flags   []GenesisFlag   `json:"flags,omitempty"`
+	Appeals []GenesisAppeal `json:"appeals,omitempty"`
+}
+
+// GenesisUser is an account registered at genesis
+type GenesisUser struct {
+	Name        string `json:"name"`
+	PubKey      []byte `json:"pub_key"`
+	RecoveryKey []byte `json:"recovery_key,omitempty"`
+	Moderator   bool   `json:"moderator,omitempty"`
+	// Nonce is the nonce of the next transaction of the user. Exported users
+	// keep it, so their transactions on the old chain can't be replayed.
+	Nonce      uint64            `json:"nonce,omitempty"`
+	KeyHistory []model.KeyChange `json:"key_history,omitempty"`
+	// Stats is the activity of the user on the exported chain
+	Stats *model.UserStats `json:"stats,omitempty"`
+}
+
+// GenesisProfile is the profile of a genesis user
+type GenesisProfile struct {
+	User    string        `json:"user"`
+	Profile model.Profile `json:"profile"`
+}
+
+// GenesisFlag is a flag of a message by a user
+type GenesisFlag struct {
+	MessageID uint64 `json:"message_id"`
+	User      string `json:"user"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// GenesisAppeal is the pending appeal of a banned user
+type GenesisAppeal struct {
+	User   string `json:"user"`
+	Reason string `json:"reason"`
+}
+
+// GenesisBalance allocates tokens to a user at genesis
+type GenesisBalance struct {
+	User   string `json:"user"`
+	Amount uint64 `json:"amount"`
+}
+
+// ParseGenesisState decodes and validates the app_state of the genesis file.
+// An empty app_state is a valid genesis with the default parameters.
+// Parameters missing from the app_state keep their default value.
+func ParseGenesisState(appStateBytes []byte) (*GenesisState, error) {
+	params := DefaultParams()
+	genesis := &GenesisState{Params: &params}
+	if len(appStateBytes) > 0 {
+		if err := json.Unmarshal(appStateBytes, genesis); err != nil {
+			return nil, fmt.Errorf("failed to decode app_state: %w", err)
+		}
+	}
+	if genesis.Params == nil {
+		genesis.Params = &params
+	}
+	return genesis, genesis.Validate()
+}
+
+func (g *GenesisState) Validate() error {
+	if err := g.Params.Validate(); err != nil {
+		return err
+	}
+	names := make(map[string]struct{}, len(g.Users))
+	for _, u := range g.Users {
+		if err := model.ValidateUserName(u.Name); err != nil {
+			return err
+		}
+		if len(u.PubKey) != ed25519.PubKeySize {
+			return fmt.Errorf("genesis user %s must have a %d byte public key", u.Name, ed25519.PubKeySize)
+		}
+		if len(u.RecoveryKey) > 0 && len(u.RecoveryKey) != ed25519.PubKeySize {
+			return fmt.Errorf("genesis user %s must have a %d byte recovery key", u.Name, ed25519.PubKeySize)
+		}
+		if _, ok := names[u.Name]; ok {
+			return fmt.Errorf("duplicate genesis user %s", u.Name)
+		}
+		names[u.Name] = struct{}{}
+	}
+	for _, role := range []struct {
+		name  string
+		users []string
+	}{{"moderator", g.Moderators}, {"admin", g.Admins}} {
+		for _, name := range role.users {
+			if _, ok := names[name]; !ok {
+				return fmt.Errorf("genesis %s %s is not a genesis user", role.name, name)
+			}
+		}
+	}
+	words := make(map[string]struct{}, len(g.WordList))
+	for _, w := range g.WordList {
+		if err := model.ValidateWord(w); err != nil {
+			return fmt.Errorf("genesis word list: %w", err)
+		}
+		if _, ok := words[w]; ok {
+			return fmt.Errorf("duplicate genesis word %s", w)
+		}
+		words[w] = struct{}{}
+	}
+	boards := make(map[string]struct{}, len(g.Boards))
+	for _, b := range g.Boards {
+		if err := model.ValidateBoardName(b.Name); err != nil {
+			return err
+		}
+		if _, ok := boards[b.Name]; ok {
+			return fmt.Errorf("duplicate genesis board %s", b.Name)
+		}
+		boards[b.Name] = struct{}{}
+	}
+	for _, name := range g.Bans {
+		if err := model.ValidateUserName(name); err != nil {
+			return fmt.Errorf("genesis ban: %w", err)
+		}
+	}
+	for _, p := range g.Profiles {
+		if _, ok := names[p.User]; !ok {
+			return fmt.Errorf("genesis profile of %s, who is not a genesis user", p.User)
+		}
+		if err := p.Profile.Validate(); err != nil {
+			return fmt.Errorf("genesis profile of %s: %w", p.User, err)
+		}
+	}
+	ids := make(map[uint64]struct{}, len(g.Messages))
+	for _, m := range g.Messages {
+		if m.ID == 0 || m.ID > g.MessageCount {
+			return fmt.Errorf("genesis message %d must have an ID between 1 and message_count", m.ID)
+		}
+		if _, ok := ids[m.ID]; ok {
+			return fmt.Errorf("duplicate genesis message %d", m.ID)
+		}
+		ids[m.ID] = struct{}{}
+		if _, ok := names[m.Sender]; !ok {
+			return fmt.Errorf("genesis message %d was sent by %s, who is not a genesis user", m.ID, m.Sender)
+		}
+	}
+	flags := make(map[GenesisFlag]struct{}, len(g.Flags))
+	for _, f := range g.Flags {
+		if f.MessageID == 0 || f.MessageID > g.MessageCount {
+			return fmt.Errorf("genesis flag of message %d must have an ID between 1 and message_count", f.MessageID)
+		}
+		if err := model.ValidateUserName(f.User); err != nil {
+			return fmt.Errorf("genesis flag: %w", err)
+		}
+		if len(f.Reason) > model.MaxReasonLength {
+			return fmt.Errorf("genesis flag of message %d by %s has a reason over %d bytes", f.MessageID, f.User, model.MaxReasonLength)
+		}
+		key := GenesisFlag{MessageID: f.MessageID, User: f.User}
+		if _, ok := flags[key]; ok {
+			return fmt.Errorf("duplicate genesis flag of message %d by %s", f.MessageID, f.User)
+		}
+		flags[key] = struct{}{}
+	}
+	banned := make(map[string]struct{}, len(g.Bans))
+	for _, name := range g.Bans {
+		banned[name] = struct{}{}
+	}
+	appeals := make(map[string]struct{}, len(g.Appeals))
+	for _, a := range g.Appeals {
+		if _, ok := banned[a.User]; !ok {
+			return fmt.Errorf("genesis appeal of %s, who is not banned", a.User)
+		}
+		if _, ok := names[a.User]; !ok {
+			return fmt.Errorf("genesis appeal of %s, who is not a genesis user", a.User)
+		}
+		if len(a.Reason) > model.MaxReasonLength {
+			return fmt.Errorf("genesis appeal of %s has a reason over %d bytes", a.User, model.MaxReasonLength)
+		}
+		if _, ok := appeals[a.User]; ok {
+			return fmt.Errorf("duplicate genesis appeal of %s", a.User)
+		}
+		appeals[a.User] = struct{}{}
+	}
+	users := make(map[string]struct{}, len(g.Balances))
+	for _, b := range g.Balances {
+		if b.User == "" {
+			return errors.New("genesis balance is missing user")
+		}
+		if _, ok := users[b.User]; ok {
+			return fmt.Errorf("duplicate genesis balance for %s", b.User)
+		}
+		users[b.User] = struct{}{}
+	}
+	return nil
+}
+
+// initGenesis writes the genesis state to the store
+func (app *ForumApp) initGenesis(store model.KVStore, genesis *GenesisState) error {
+	app.state.Params = *genesis.Params
+	// A new chain starts with the latest layout and has nothing to migrate
+	if err := model.SetSchemaVersion(store, model.LatestSchemaVersion()); err != nil {
+		return err
+	}
+	moderators := make(map[string]bool, len(genesis.Moderators))
+	for _, name := range genesis.Moderators {
+		moderators[name] = true
+	}
+	admins := make(map[string]bool, len(genesis.Admins))
+	for _, name := range genesis.Admins {
+		admins[name] = true
+	}
+	for _, u := range genesis.Users {
+		user := &model.User{
+			Name:        u.Name,
+			PubKey:      u.PubKey,
+			RecoveryKey: u.RecoveryKey,
+			Moderator:   u.Moderator || moderators[u.Name],
+			Admin:       admins[u.Name],
+			Nonce:       u.Nonce,
+			KeyHistory:  u.KeyHistory,
+		}
+		if u.Stats != nil {
+			user.NumMessages = u.Stats.Posts
+			user.FirstPostHeight = u.Stats.FirstPostHeight
+			user.LastPostHeight = u.Stats.LastPostHeight
+			user.Strikes = u.Stats.Strikes
+			user.Edits = u.Stats.Edits
+			user.Deletions = u.Stats.Deletions
+			if u.Stats.Posts > 0 {
+				if err := model.SetRank(store, user); err != nil {
+					return err
+				}
+			}
+		}
+		if err := model.SetUser(store, user); err != nil {
+			return err
+		}
+	}
+	for _, name := range genesis.Bans {
+		// Banned users keep the strikes of their stats
+		u, err := model.GetUser(store, name)
+		if errors.Is(err, model.ErrNotFound) {
+			u, err = &model.User{Name: name}, nil
+		}
+		if err != nil {
+			return err
+		}
+		u.Banned = true
+		if err := model.SetUser(store, u); err != nil {
+			return err
+		}
+	}
+	for _, p := range genesis.Profiles {
+		if err := model.SetProfile(store, p.User, &p.Profile); err != nil {
+			return err
+		}
+	}
+	if len(genesis.Messages) > 0 || genesis.MessageCount > 0 {
+		if err := model.ImportMessages(store, genesis.Messages, genesis.MessageCount); err != nil {
+			return err
+		}
+		app.state.Size = int64(len(genesis.Messages))
+	}
+	for _, f := range genesis.Flags {
+		if err := model.AddFlag(store, f.MessageID, model.Flag{User: f.User, Reason: f.Reason}); err != nil {
+			return err
+		}
+	}
+	for _, a := range genesis.Appeals {
+		u, err := model.GetUser(store, a.User)
+		if err != nil {
+			return err
+		}
+		u.Appeal = &model.Appeal{Reason: a.Reason}
+		if err := model.SetUser(store, u); err != nil {
+			return err
+		}
+	}
+	if genesis.History != "" {
+		if err := model.SetHistory(store, genesis.History); err != nil {
+			return err
+		}
+	}
+	if len(genesis.WordList) > 0 {
+		if err := model.SetWordList(store, genesis.WordList); err != nil {
+			return err
+		}
+	}
+	if len(genesis.Boards) > 0 {
+		if err := model.SetBoards(store, genesis.Boards); err != nil {
+			return err
+		}
+	}
+	for _, b := range genesis.Balances {
+		if err := bank.SetBalance(store, b.User, b.Amount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package forum
+
+import (
+	"fmt"
+
+	"github.com/alijnmerchant21/forum-updated/model"
+)
+
+// Pruning strategies for the history of the state, which Query reads at
+// past heights. They mirror the ones of the Cosmos SDK.
+const (
+	// PruningDefault keeps the last 362880 heights, about three weeks of
+	// five second blocks, and prunes every 10 blocks
+	PruningDefault = "default"
+	// PruningNothing keeps the state at every height
+	PruningNothing = "nothing"
+	// PruningEverything keeps no history; only the latest state can be queried
+	PruningEverything = "everything"
+	// PruningCustom uses keep_recent and interval from the config
+	PruningCustom = "custom"
+)
+
+const (
+	defaultKeepRecent      = 362880
+	defaultPruningInterval = 10
+)
+
+// HistoryConfig sets how much of the state at past heights this node keeps.
+// It is local to the node and doesn't affect consensus.
+type HistoryConfig struct {
+	// Pruning is one of "default", "nothing", "everything" or "custom"
+	Pruning string `toml:"pruning"`
+	// KeepRecent is the number of recent heights kept with the "custom" strategy
+	KeepRecent int64 `toml:"keep_recent"`
+	// Interval is the number of blocks between two prunings with the "custom" strategy
+	Interval int64 `toml:"interval"`
+}
+
+// Enabled reports whether the node records the history of the state
+func (c HistoryConfig) Enabled() bool {
+	return c.Pruning != PruningEverything
+}
+
+// retention returns the number of heights kept and how often the older ones
+// are pruned; 0 keeps every height.
+func (c HistoryConfig) retention() (keepRecent int64, interval int64) {
+	switch c.Pruning {
+	case PruningDefault:
+		return defaultKeepRecent, defaultPruningInterval
+	case PruningCustom:
+		return c.KeepRecent, c.Interval
+	default:
+		return 0, 0
+	}
+}
+
+func (c HistoryConfig) Validate() error {
+	switch c.Pruning {
+	case PruningDefault, PruningNothing, PruningEverything:
+		return nil
+	case PruningCustom:
+		if c.KeepRecent < 1 {
+			return fmt.Errorf("history keep_recent must be at least 1, got %d", c.KeepRecent)
+		}
+		if c.Interval < 1 {
+			return fmt.Errorf("history interval must be at least 1, got %d", c.Interval)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown history pruning strategy %q", c.Pruning)
+	}
+}
+
+// commitBlock writes the block staged in onGoingBlock, with the history of the
+// state if it is enabled, and prunes the heights that are not kept anymore:
+// the ones older than the history settings keep, and the ones below
+// retainHeight, whose blocks CometBFT prunes.
+func (app *ForumApp) commitBlock(height int64, retainHeight int64) error {
+	if !app.history.Enabled() {
+		return app.onGoingBlock.Write()
+	}
+	if err := app.onGoingBlock.WriteVersion(height); err != nil {
+		return err
+	}
+	keepRecent, interval := app.history.retention()
+	if interval == 0 {
+		interval = defaultPruningInterval
+	}
+	// Pruning walks the whole history, so it only runs every interval blocks
+	if height%interval != 0 {
+		return nil
+	}
+	pruneHeight := retainHeight
+	if keepRecent > 0 && height-keepRecent+1 > pruneHeight {
+		pruneHeight = height - keepRecent + 1
+	}
+	if pruneHeight <= 1 {
+		return nil
+	}
+	_, err := app.state.DB.PruneVersions(pruneHeight)
+	return err
+}
+
+// stateAt returns the state at the given query height; 0 is the latest state.
+func (app *ForumApp) stateAt(height int64) (model.KVStore, error) {
+	if height == 0 || height == app.state.Height {
+		return app.state.DB, nil
+	}
+	if height < 0 || height > app.state.Height {
+		return nil, newQueryError(CodeTypeHeightUnavailable, "height %d is not committed yet, the latest height is %d", height, app.state.Height)
+	}
+	if !app.history.Enabled() {
+		return nil, newQueryError(CodeTypeHeightUnavailable, "this node keeps no history of the state (history pruning = %q)", PruningEverything)
+	}
+	return app.state.DB.AtHeight(height)
+}
@@ -0,0 +1,189 @@
+package forum
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/alijnmerchant21/forum-updated/model"
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/cometbft/cometbft/crypto/ed25519"
+)
+
+// signingKey returns the key that must have signed the transaction. Users
+// recovering their own account sign with their recovery key.
+func signingKey(u *model.User, tx *model.Tx) ed25519.PubKey {
+	if tx.Type == model.TxTypeRecover {
+		recover, err := tx.ParseRecover()
+		if err == nil && recover.User == tx.Sender {
+			return u.RecoveryKey
+		}
+	}
+	return u.PubKey
+}
+
+// validateKeyTx checks the transactions that manage the keys of an account
+func (app *ForumApp) validateKeyTx(store model.KVStore, tx *model.Tx) *txError {
+	sender, err := model.GetUser(store, tx.Sender)
+	if err != nil {
+		return newTxError(CodeTypeEncodingError, "Failed to load sender")
+	}
+	switch tx.Type {
+	case model.TxTypeRotateKey:
+		rotate, err := tx.ParseRotateKey()
+		if err != nil {
+			return newTxError(CodeTypeInvalidTxFormat, "%v", err)
+		}
+		if bytes.Equal(rotate.PubKey, sender.PubKey) {
+			return newTxError(CodeTypeInvalidTxFormat, "The new key is the current key")
+		}
+	case model.TxTypeSetRecovery:
+		if _, err := tx.ParseSetRecovery(); err != nil {
+			return newTxError(CodeTypeInvalidTxFormat, "%v", err)
+		}
+	case model.TxTypeRecover:
+		recover, err := tx.ParseRecover()
+		if err != nil {
+			return newTxError(CodeTypeInvalidTxFormat, "%v", err)
+		}
+		target, err := model.GetUser(store, recover.User)
+		if errors.Is(err, model.ErrNotFound) || (err == nil && len(target.PubKey) == 0) {
+			return newTxError(CodeTypeUnknownUser, "User %s is not registered", recover.User)
+		}
+		if err != nil {
+			return newTxError(CodeTypeEncodingError, "Failed to load user")
+		}
+		// The recovery key was checked with the signature, moderators are checked here
+		if recover.User != tx.Sender {
+			if app.state.Params.Recovery.ModeratorQuorum == 0 {
+				return newTxError(CodeTypeNoRecovery, "Recovery by moderators is disabled")
+			}
+			if !sender.Moderator {
+				return newTxError(CodeTypeUnauthorized, "Only moderators can recover the account of another user")
+			}
+			// A moderator could otherwise reset the approvals gathered for the
+			// pending recovery, or a recovery requested with the recovery key
+			if pending := target.PendingRecovery; pending != nil && !bytes.Equal(pending.PubKey, recover.PubKey) {
+				return newTxError(CodeTypeRecoveryPending, "A recovery of %s to another key is pending", recover.User)
+			}
+		}
+		if bytes.Equal(recover.PubKey, target.PubKey) {
+			return newTxError(CodeTypeInvalidTxFormat, "The new key is the current key")
+		}
+	case model.TxTypeCancelRecovery:
+		if sender.PendingRecovery == nil {
+			return newTxError(CodeTypeNoRecovery, "No recovery is pending")
+		}
+	}
+	return nil
+}
+
+// deliverKeyTx executes a transaction validated by validateKeyTx
+func (app *ForumApp) deliverKeyTx(tx *model.Tx, height int64) ([]abci.Event, *txError) {
+	sender, err := model.GetUser(app.onGoingBlock, tx.Sender)
+	if err != nil {
+		panic(err)
+	}
+	var events []abci.Event
+	switch tx.Type {
+	case model.TxTypeRotateKey:
+		rotate, err := tx.ParseRotateKey()
+		if err != nil {
+			return nil, newTxError(CodeTypeInvalidTxFormat, "%v", err)
+		}
+		sender.ReplaceKey(rotate.PubKey, height, model.KeyChangeRotation)
+		events = append(events, keyChangeEvent(sender, model.KeyChangeRotation))
+	case model.TxTypeSetRecovery:
+		set, err := tx.ParseSetRecovery()
+		if err != nil {
+			return nil, newTxError(CodeTypeInvalidTxFormat, "%v", err)
+		}
+		sender.RecoveryKey = set.RecoveryKey
+		events = append(events, userEvent(EventTypeSetRecovery, sender.Name))
+	case model.TxTypeRecover:
+		recover, err := tx.ParseRecover()
+		if err != nil {
+			return nil, newTxError(CodeTypeInvalidTxFormat, "%v", err)
+		}
+		events = app.requestRecovery(tx.Sender, recover, height)
+		// the sender may be the user being recovered
+		sender, err = model.GetUser(app.onGoingBlock, tx.Sender)
+		if err != nil {
+			panic(err)
+		}
+	case model.TxTypeCancelRecovery:
+		sender.PendingRecovery = nil
+		events = append(events, userEvent(EventTypeRecoveryCancel, sender.Name))
+	}
+	if err := model.SetUser(app.onGoingBlock, sender); err != nil {
+		panic(err)
+	}
+	return events, nil
+}
+
+// requestRecovery records an approval of a key reset. A request with the
+// recovery key for another key than the pending one starts a new recovery,
+// with a new delay; moderators can only approve the pending key.
+func (app *ForumApp) requestRecovery(sender string, recover *model.RecoverTx, height int64) []abci.Event {
+	target, err := model.GetUser(app.onGoingBlock, recover.User)
+	if err != nil {
+		panic(err)
+	}
+	pending := target.PendingRecovery
+	if pending == nil || !bytes.Equal(pending.PubKey, recover.PubKey) {
+		pending = &model.Recovery{PubKey: recover.PubKey, Height: height}
+	}
+	if sender == recover.User {
+		pending.RecoveryKey = true
+	} else {
+		pending.Approve(sender)
+	}
+	target.PendingRecovery = pending
+	if err := model.SetUser(app.onGoingBlock, target); err != nil {
+		panic(err)
+	}
+	if err := model.AddPendingRecovery(app.onGoingBlock, target.Name); err != nil {
+		panic(err)
+	}
+	return []abci.Event{recoveryRequestEvent(sender, *recover)}
+}
+
+// completeRecoveries resets the keys of the accounts whose recovery is
+// approved and waited long enough at the given height
+func (app *ForumApp) completeRecoveries(height int64) []abci.Event {
+	names, err := model.PendingRecoveries(app.onGoingBlock)
+	if err != nil {
+		panic(err)
+	}
+	params := app.state.Params.Recovery
+	var events []abci.Event
+	var stillPending []string
+	for _, name := range names {
+		u, err := model.GetUser(app.onGoingBlock, name)
+		if err != nil {
+			panic(err)
+		}
+		pending := u.PendingRecovery
+		if pending == nil {
+			// cancelled
+			continue
+		}
+		approved := pending.RecoveryKey ||
+			(params.ModeratorQuorum > 0 && len(pending.Moderators) >= params.ModeratorQuorum)
+		if !approved || height < pending.Height+params.DelayBlocks {
+			stillPending = append(stillPending, name)
+			continue
+		}
+		u.ReplaceKey(pending.PubKey, height, model.KeyChangeRecovery)
+		u.PendingRecovery = nil
+		if err := model.SetUser(app.onGoingBlock, u); err != nil {
+			panic(err)
+		}
+		events = append(events, keyChangeEvent(u, model.KeyChangeRecovery))
+	}
+	if len(stillPending) != len(names) {
+		if err := model.SetPendingRecoveries(app.onGoingBlock, stillPending); err != nil {
+			panic(err)
+		}
+	}
+	return events
+}
@@ -0,0 +1,20 @@
+package forum
+
+import (
+	"io"
+
+	cmtflags "github.com/cometbft/cometbft/libs/cli/flags"
+	cmtlog "github.com/cometbft/cometbft/libs/log"
+)
+
+// NewLogger returns a logger writing to w with the log_level and log_format
+// of the configuration
+func NewLogger(cfg *Config, w io.Writer) (cmtlog.Logger, error) {
+	var logger cmtlog.Logger
+	if cfg.LogFormat == LogFormatJSON {
+		logger = cmtlog.NewTMJSONLogger(cmtlog.NewSyncWriter(w))
+	} else {
+		logger = cmtlog.NewTMLogger(cmtlog.NewSyncWriter(w))
+	}
+	return cmtflags.ParseLogLevel(cfg.LogLevel, logger, defaultLogLevel)
+}
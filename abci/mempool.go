@@ -0,0 +1,119 @@
+package forum
+
+import (
+	"sort"
+
+	"github.com/alijnmerchant21/forum-updated/model"
+	cmttypes "github.com/cometbft/cometbft/types"
+)
+
+// Priority policies for ordering transactions in our proposals.
+// CometBFT v0.38 no longer orders its mempool by priority, so the
+// application applies the policy itself in PrepareProposal.
+const (
+	// PriorityPolicyFIFO keeps the order in which the mempool gave us the senders
+	PriorityPolicyFIFO = "fifo"
+	// PriorityPolicyModerator puts transactions sent by moderators first
+	PriorityPolicyModerator = "moderator"
+	// PriorityPolicyFee puts the posts paying the highest fees first
+	PriorityPolicyFee = "fee"
+)
+
+func isPriorityPolicy(policy string) bool {
+	switch policy {
+	case PriorityPolicyFIFO, PriorityPolicyModerator, PriorityPolicyFee:
+		return true
+	default:
+		return false
+	}
+}
+
+// txPriority returns the priority of a transaction under the configured policy.
+// Higher values are proposed first.
+func (app *ForumApp) txPriority(typed *model.Tx) int64 {
+	sender := typed.Sender
+	var fee uint64
+	if post, err := typed.ParsePost(); err == nil {
+		fee = app.state.Params.Fees.PostFeeFor(post.ToMessage(sender))
+	}
+
+	switch app.mempool.PriorityPolicy {
+	case PriorityPolicyModerator:
+		u, err := model.GetUser(app.state.DB, sender)
+		if err == nil && u.Moderator {
+			return 1
+		}
+	case PriorityPolicyFee:
+		return int64(fee)
+	}
+	return 0
+}
+
+// prioritize sorts the transactions by the priority of their senders. A
+// sender gets the highest priority of its transactions, which stay together
+// in nonce order: a block only accepts the nonces of a sender in sequence.
+// Senders of the same priority keep the mempool order.
+func (app *ForumApp) prioritize(txs [][]byte) {
+	type proposedTx struct {
+		tx     []byte
+		sender string
+		nonce  uint64
+	}
+	proposed := make([]proposedTx, len(txs))
+	priorities := make(map[string]int64)
+	firstSeen := make(map[string]int)
+	for i, tx := range txs {
+		proposed[i].tx = tx
+		typed, err := model.ParseTx(tx)
+		if err != nil {
+			continue
+		}
+		sender := typed.Sender
+		proposed[i].sender, proposed[i].nonce = sender, typed.Nonce
+		priority := app.txPriority(typed)
+		if p, ok := priorities[sender]; !ok || priority > p {
+			priorities[sender] = priority
+		}
+		if _, ok := firstSeen[sender]; !ok {
+			firstSeen[sender] = i
+		}
+	}
+	sort.SliceStable(proposed, func(a, b int) bool {
+		txA, txB := proposed[a], proposed[b]
+		if txA.sender == txB.sender {
+			return txA.nonce < txB.nonce
+		}
+		if pA, pB := priorities[txA.sender], priorities[txB.sender]; pA != pB {
+			return pA > pB
+		}
+		return firstSeen[txA.sender] < firstSeen[txB.sender]
+	})
+	for i, p := range proposed {
+		txs[i] = p.tx
+	}
+}
+
+// limitTxBytes drops the transactions that do not fit in maxTxBytes anymore
+func limitTxBytes(txs [][]byte, maxTxBytes int64) [][]byte {
+	var size int64
+	for i, tx := range txs {
+		size += cmttypes.ComputeProtoSizeForTxs([]cmttypes.Tx{tx})
+		if size > maxTxBytes {
+			return txs[:i]
+		}
+	}
+	return txs
+}
+
+// trackSender counts a transaction of the sender that is now in the mempool.
+// It reports false if the sender already reached the configured limit.
+// The counts are reset on every Commit and rebuilt while CometBFT rechecks
+// the transactions left in the mempool, so the limit needs mempool.recheck.
+func (app *ForumApp) trackSender(sender string) bool {
+	limit := app.mempool.MaxTxsPerSender
+	if limit > 0 && app.mempoolSenders[sender] >= limit {
+		return false
+	}
+	app.mempoolSenders[sender]++
+	return true
+}
@@ -0,0 +1,149 @@
+package forum
+
+import (
+	"errors"
+
+	"github.com/alijnmerchant21/forum-updated/model"
+	abci "github.com/cometbft/cometbft/abci/types"
+)
+
+// validateModerationTx checks the flags of messages, the bans by moderators
+// and the appeals of bans
+func validateModerationTx(store model.KVStore, tx *model.Tx) *txError {
+	sender, err := model.GetUser(store, tx.Sender)
+	if err != nil {
+		return newTxError(CodeTypeEncodingError, "Failed to load sender")
+	}
+	switch tx.Type {
+	case model.TxTypeFlag:
+		flag, err := tx.ParseFlag()
+		if err != nil {
+			return newTxError(CodeTypeInvalidTxFormat, "%v", err)
+		}
+		if txErr := checkMessageID(store, flag.MessageID); txErr != nil {
+			return txErr
+		}
+		flagged, err := model.HasFlagged(store, flag.MessageID, tx.Sender)
+		if err != nil {
+			return newTxError(CodeTypeEncodingError, "Failed to load flags")
+		}
+		if flagged {
+			return newTxError(CodeTypeDuplicate, "%s already flagged message %d", tx.Sender, flag.MessageID)
+		}
+	case model.TxTypeBanUser:
+		ban, err := tx.ParseBanUser()
+		if err != nil {
+			return newTxError(CodeTypeInvalidTxFormat, "%v", err)
+		}
+		if !sender.Moderator {
+			return newTxError(CodeTypeUnauthorized, "Only moderators can ban users")
+		}
+		target, err := model.GetUser(store, ban.User)
+		if errors.Is(err, model.ErrNotFound) {
+			return newTxError(CodeTypeUnknownUser, "User %s is not registered", ban.User)
+		}
+		if err != nil {
+			return newTxError(CodeTypeEncodingError, "Failed to load user")
+		}
+		if target.Banned {
+			return newTxError(CodeTypeDuplicate, "%s is already banned", ban.User)
+		}
+	case model.TxTypeAppeal:
+		if _, err := tx.ParseAppeal(); err != nil {
+			return newTxError(CodeTypeInvalidTxFormat, "%v", err)
+		}
+		if !sender.Banned {
+			return newTxError(CodeTypeUnauthorized, "Only banned users can appeal")
+		}
+		if sender.Appeal != nil {
+			return newTxError(CodeTypeDuplicate, "An appeal of %s is already pending", tx.Sender)
+		}
+	case model.TxTypeResolveAppeal:
+		resolve, err := tx.ParseResolveAppeal()
+		if err != nil {
+			return newTxError(CodeTypeInvalidTxFormat, "%v", err)
+		}
+		if !sender.Moderator {
+			return newTxError(CodeTypeUnauthorized, "Only moderators can resolve appeals")
+		}
+		target, err := model.GetUser(store, resolve.User)
+		if errors.Is(err, model.ErrNotFound) {
+			return newTxError(CodeTypeUnknownUser, "User %s is not registered", resolve.User)
+		}
+		if err != nil {
+			return newTxError(CodeTypeEncodingError, "Failed to load user")
+		}
+		if target.Appeal == nil {
+			return newTxError(CodeTypeNoAppeal, "%s has no pending appeal", resolve.User)
+		}
+	}
+	return nil
+}
+
+// checkMessageID rejects references to messages that were never posted
+func checkMessageID(store model.KVStore, id uint64) *txError {
+	count, err := model.MessageCount(store)
+	if err != nil {
+		return newTxError(CodeTypeEncodingError, "Failed to load messages")
+	}
+	if id == 0 || id > count {
+		return newTxError(CodeTypeUnknownMessage, "There is no message %d", id)
+	}
+	return nil
+}
+
+// deliverModerationTx executes a transaction validated by validateModerationTx
+func (app *ForumApp) deliverModerationTx(tx *model.Tx, height int64) ([]abci.Event, *txError) {
+	switch tx.Type {
+	case model.TxTypeFlag:
+		flag, err := tx.ParseFlag()
+		if err != nil {
+			return nil, newTxError(CodeTypeInvalidTxFormat, "%v", err)
+		}
+		if err := model.AddFlag(app.onGoingBlock, flag.MessageID, model.Flag{User: tx.Sender, Reason: flag.Reason, Height: height}); err != nil {
+			panic(err)
+		}
+		return []abci.Event{flagEvent(tx.Sender, *flag)}, nil
+	case model.TxTypeBanUser:
+		ban, err := tx.ParseBanUser()
+		if err != nil {
+			return nil, newTxError(CodeTypeInvalidTxFormat, "%v", err)
+		}
+		if err := UpdateOrSetUser(app.onGoingBlock, ban.User, true); err != nil {
+			panic(err)
+		}
+		return []abci.Event{banEvent(model.BanTx{UserName: ban.User, Reason: ban.Reason})}, nil
+	case model.TxTypeAppeal:
+		appeal, err := tx.ParseAppeal()
+		if err != nil {
+			return nil, newTxError(CodeTypeInvalidTxFormat, "%v", err)
+		}
+		err = app.updateUser(tx.Sender, func(u *model.User) {
+			u.Appeal = &model.Appeal{Reason: appeal.Reason, Height: height}
+		})
+		if err != nil {
+			panic(err)
+		}
+		return []abci.Event{appealEvent(tx.Sender, *appeal)}, nil
+	case model.TxTypeResolveAppeal:
+		resolve, err := tx.ParseResolveAppeal()
+		if err != nil {
+			return nil, newTxError(CodeTypeInvalidTxFormat, "%v", err)
+		}
+		err = app.updateUser(resolve.User, func(u *model.User) {
+			u.Appeal = nil
+			if resolve.Accept {
+				u.Banned = false
+			}
+		})
+		if err != nil {
+			panic(err)
+		}
+		events := []abci.Event{appealResolvedEvent(tx.Sender, *resolve)}
+		if resolve.Accept {
+			events = append(events, userEvent(EventTypeUnban, resolve.User))
+		}
+		return events, nil
+	}
+	return nil, nil
+}
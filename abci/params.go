@@ -0,0 +1,81 @@
+package forum
+
+import (
+	"errors"
+
+	"github.com/alijnmerchant21/forum-updated/bank"
+	"github.com/alijnmerchant21/forum-updated/ratelimit"
+)
+
+// Params are the consensus parameters of the forum. They are part of the
+// application state, so all validators apply the same rules. They are set
+// in the genesis file.
+type Params struct {
+	Fees      bank.FeeParams   `json:"fees"`
+	RateLimit ratelimit.Params `json:"rate_limit"`
+	Recovery  RecoveryParams   `json:"recovery"`
+	Upgrade   UpgradeParams    `json:"upgrade"`
+	// MigrationHeight is the height at which the pending data migrations run,
+	// for nodes that don't run them on startup; 0 means no height is scheduled
+	MigrationHeight int64 `json:"migration_height,omitempty"`
+}
+
+// RecoveryParams control how the key of an account can be reset
+type RecoveryParams struct {
+	// DelayBlocks is how long a recovery waits before it takes effect,
+	// leaving the owner of the current key time to cancel it
+	DelayBlocks int64 `json:"delay_blocks"`
+	// ModeratorQuorum is the number of moderators that can recover an
+	// account together; 0 disables recovery by moderators
+	ModeratorQuorum int `json:"moderator_quorum"`
+}
+
+// UpgradeParams control how moderators schedule software upgrades
+type UpgradeParams struct {
+	// Quorum is the number of moderators that must approve an upgrade
+	// proposal to schedule it; 0 disables upgrades
+	Quorum int `json:"quorum"`
+	// VotingPeriod is the number of blocks a proposal stays open for approval
+	VotingPeriod int64 `json:"voting_period"`
+}
+
+// DefaultParams are used when the genesis file does not set any
+func DefaultParams() Params {
+	return Params{
+		Fees: bank.FeeParams{
+			PostFee:    0,
+			FeePerByte: 0,
+		},
+		RateLimit: ratelimit.Params{
+			MaxPosts:     0,
+			WindowBlocks: 0,
+		},
+		Recovery: RecoveryParams{
+			DelayBlocks:     100,
+			ModeratorQuorum: 0,
+		},
+		Upgrade: UpgradeParams{
+			Quorum:       0,
+			VotingPeriod: 1000,
+		},
+	}
+}
+
+func (p Params) Validate() error {
+	if p.Recovery.DelayBlocks < 0 {
+		return errors.New("recovery delay_blocks can't be negative")
+	}
+	if p.MigrationHeight < 0 {
+		return errors.New("migration_height can't be negative")
+	}
+	if p.Recovery.ModeratorQuorum < 0 {
+		return errors.New("recovery moderator_quorum can't be negative")
+	}
+	if p.Upgrade.Quorum < 0 {
+		return errors.New("upgrade quorum can't be negative")
+	}
+	if p.Upgrade.VotingPeriod < 1 {
+		return errors.New("upgrade voting_period must be at least 1")
+	}
+	return p.RateLimit.Validate()
+}
@@ -0,0 +1,240 @@
+package forum
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/alijnmerchant21/forum-updated/bank"
+	"github.com/alijnmerchant21/forum-updated/model"
+	abci "github.com/cometbft/cometbft/abci/types"
+)
+
+const (
+	defaultLeaderboardSize = 10
+	// defaultHistoryPageSize and maxHistoryPageSize bound the pages of the chat history
+	defaultHistoryPageSize = 20
+	maxHistoryPageSize     = 100
+)
+
+// Query paths. The response value is the result encoded as JSON.
+const (
+	// QueryPathUser returns the account of the user named in the data, with
+	// its public key and next nonce
+	QueryPathUser = "/user"
+	// QueryPathBalance returns the balance of the user
+	QueryPathBalance = "/balance"
+	// QueryPathProfile returns the profile of the user
+	QueryPathProfile = "/profile"
+	// QueryPathLeaderboard returns the stats of the most active users; the
+	// query data is the number of users, 10 by default
+	QueryPathLeaderboard = "/leaderboard"
+	// QueryPathKeyHistory returns the keys the user had before the current one
+	QueryPathKeyHistory = "/key_history"
+	// QueryPathFlags returns the flags of the message with the ID given as data
+	QueryPathFlags = "/flags"
+	// QueryPathMessages returns the messages posted by the user
+	QueryPathMessages = "/messages"
+	// QueryPathHistory returns a page of the chat history; the data is
+	// "<page>" or "<page>/<entries per page>", page 1 of 20 entries by default
+	QueryPathHistory = "/history"
+	// QueryPathBans returns the names of the banned users
+	QueryPathBans = "/bans"
+	// QueryPathModerators returns the names of the moderators
+	QueryPathModerators = "/moderators"
+	// QueryPathParams returns the consensus parameters of the forum
+	QueryPathParams = "/params"
+	// QueryPathProposals returns the upgrade proposals
+	QueryPathProposals = "/proposals"
+)
+
+// queryError is a query that can't be answered because of its path, data or
+// height rather than a failure of the node. It is returned with a code, like
+// a rejected transaction, instead of failing the ABCI connection.
+type queryError struct {
+	code uint32
+	log  string
+}
+
+func newQueryError(code uint32, format string, args ...interface{}) *queryError {
+	return &queryError{code: code, log: fmt.Sprintf(format, args...)}
+}
+
+func (e *queryError) Error() string {
+	return e.log
+}
+
+// queryErrorCode returns the code of the response to a query that failed with
+// err, or false if err is an internal failure
+func queryErrorCode(err error) (uint32, bool) {
+	var qerr *queryError
+	switch {
+	case errors.As(err, &qerr):
+		return qerr.code, true
+	case errors.Is(err, model.ErrNotFound):
+		return CodeTypeNotFound, true
+	case errors.Is(err, model.ErrHeightPruned):
+		return CodeTypeHeightUnavailable, true
+	}
+	return 0, false
+}
+
+// queryHandler answers the queries of a path from the state at the height
+// of the query
+type queryHandler func(store model.KVStore, data []byte) (interface{}, error)
+
+var queryHandlers = map[string]queryHandler{
+	QueryPathUser: func(store model.KVStore, data []byte) (interface{}, error) {
+		return model.GetUser(store, string(data))
+	},
+	QueryPathBalance: func(store model.KVStore, data []byte) (interface{}, error) {
+		return bank.GetBalance(store, string(data))
+	},
+	QueryPathProfile: func(store model.KVStore, data []byte) (interface{}, error) {
+		return model.GetProfile(store, string(data))
+	},
+	QueryPathLeaderboard: func(store model.KVStore, data []byte) (interface{}, error) {
+		limit := defaultLeaderboardSize
+		if len(data) > 0 {
+			var err error
+			limit, err = strconv.Atoi(string(data))
+			if err != nil || limit <= 0 {
+				return nil, newQueryError(CodeTypeInvalidQuery, "invalid leaderboard size %q", data)
+			}
+		}
+		return model.Leaderboard(store, limit)
+	},
+	QueryPathKeyHistory: func(store model.KVStore, data []byte) (interface{}, error) {
+		u, err := model.GetUser(store, string(data))
+		if err != nil {
+			return nil, err
+		}
+		return u.KeyHistory, nil
+	},
+	QueryPathFlags: func(store model.KVStore, data []byte) (interface{}, error) {
+		id, err := strconv.ParseUint(string(data), 10, 64)
+		if err != nil {
+			return nil, newQueryError(CodeTypeInvalidQuery, "invalid message ID %q", data)
+		}
+		return model.GetFlags(store, id)
+	},
+	QueryPathMessages: func(store model.KVStore, data []byte) (interface{}, error) {
+		return model.GetMessagesBySender(store, string(data))
+	},
+	QueryPathHistory: func(store model.KVStore, data []byte) (interface{}, error) {
+		page, limit, err := parsePage(string(data))
+		if err != nil {
+			return nil, err
+		}
+		return model.GetHistoryPage(store, page, limit)
+	},
+	QueryPathBans: func(store model.KVStore, _ []byte) (interface{}, error) {
+		return listUsers(store, func(u *model.User) bool { return u.Banned })
+	},
+	QueryPathModerators: func(store model.KVStore, _ []byte) (interface{}, error) {
+		return listUsers(store, func(u *model.User) bool { return u.Moderator })
+	},
+	QueryPathParams: func(store model.KVStore, _ []byte) (interface{}, error) {
+		state, err := readAppState(store)
+		if err != nil {
+			return nil, err
+		}
+		return state.Params, nil
+	},
+	QueryPathProposals: func(store model.KVStore, _ []byte) (interface{}, error) {
+		proposals, err := model.UpgradeProposals(store)
+		if proposals == nil {
+			proposals = []model.UpgradeProposal{}
+		}
+		return proposals, err
+	},
+}
+
+// listUsers returns the names of the users matching the filter, never nil
+func listUsers(store model.KVStore, filter func(*model.User) bool) ([]string, error) {
+	names, err := model.ListUsers(store, filter)
+	if names == nil {
+		names = []string{}
+	}
+	return names, err
+}
+
+// parsePage reads the page of a history query, "<page>" or "<page>/<limit>"
+func parsePage(data string) (int, int, error) {
+	page, limit := 1, defaultHistoryPageSize
+	if data == "" {
+		return page, limit, nil
+	}
+	pageStr, limitStr, hasLimit := strings.Cut(data, "/")
+	var err error
+	if page, err = strconv.Atoi(pageStr); err != nil || page < 1 {
+		return 0, 0, newQueryError(CodeTypeInvalidQuery, "invalid history page %q", data)
+	}
+	if hasLimit {
+		if limit, err = strconv.Atoi(limitStr); err != nil || limit < 1 || limit > maxHistoryPageSize {
+			return 0, 0, newQueryError(CodeTypeInvalidQuery, "invalid history page size %q, the maximum is %d", limitStr, maxHistoryPageSize)
+		}
+	}
+	return page, limit, nil
+}
+
+// Query routes the query to the handler of its path. Queries without a path
+// are the original ones: the data is the name of a user, whose messages are
+// returned, or "history" for the whole chat history. The state is read at
+// query.Height, or at the latest height if it is 0, as far as the history
+// kept by the node allows.
+// A query that can't be answered, e.g. for an unknown user or a pruned
+// height, gets a response with a non-zero code; only internal failures are
+// returned as errors.
+func (app *ForumApp) Query(ctx context.Context, query *abci.RequestQuery) (*abci.ResponseQuery, error) {
+	resp := abci.ResponseQuery{Key: query.Data, Height: app.state.Height}
+	if query.Height != 0 {
+		resp.Height = query.Height
+	}
+	value, err := app.query(query)
+	if err != nil {
+		code, ok := queryErrorCode(err)
+		if !ok {
+			return nil, err
+		}
+		resp.Code = code
+		resp.Log = err.Error()
+		return &resp, nil
+	}
+	resp.Log = string(value)
+	resp.Value = value
+	return &resp, nil
+}
+
+// query returns the value of the response to a query
+func (app *ForumApp) query(query *abci.RequestQuery) ([]byte, error) {
+	store, err := app.stateAt(query.Height)
+	if err != nil {
+		return nil, err
+	}
+
+	if query.Path == "" && string(query.Data) == "history" {
+		messages, err := model.FetchHistory(store)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(messages), nil
+	}
+
+	path := query.Path
+	if path == "" {
+		path = QueryPathMessages
+	}
+	handler, ok := queryHandlers[path]
+	if !ok {
+		return nil, newQueryError(CodeTypeInvalidQuery, "unknown query path %q", query.Path)
+	}
+	result, err := handler(store, query.Data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(result)
+}
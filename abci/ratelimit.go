@@ -0,0 +1,43 @@
+package forum
+
+import (
+	"github.com/alijnmerchant21/forum-updated/model"
+	"github.com/alijnmerchant21/forum-updated/ratelimit"
+)
+
+// postBudget keeps track of how many more posts each sender can make in a
+// block, starting from what the committed state allows at that height.
+type postBudget struct {
+	store     model.KVStore
+	height    int64
+	params    ratelimit.Params
+	remaining map[string]uint64
+}
+
+func (app *ForumApp) newPostBudget(height int64) *postBudget {
+	return &postBudget{
+		store:     app.state.DB,
+		height:    height,
+		params:    app.state.Params.RateLimit,
+		remaining: make(map[string]uint64),
+	}
+}
+
+// take uses up one post of the sender. It reports false if the sender
+// reached the rate limit.
+func (b *postBudget) take(sender string) (bool, error) {
+	remaining, ok := b.remaining[sender]
+	if !ok {
+		var err error
+		remaining, err = ratelimit.Remaining(b.store, sender, b.height, b.params)
+		if err != nil {
+			return false, err
+		}
+	}
+	if remaining == 0 {
+		b.remaining[sender] = 0
+		return false, nil
+	}
+	b.remaining[sender] = remaining - 1
+	return true, nil
+}
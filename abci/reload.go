@@ -0,0 +1,157 @@
+package forum
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// The curse words of the validator can be changed while the node runs. A new
+// list is validated when it is loaded but only takes effect after the block
+// in progress is committed, so all the calls of a block see the same words.
+
+// pendingWords holds the curse words loaded since the last commit
+type pendingWords struct {
+	mtx   sync.Mutex
+	words *string
+}
+
+func (p *pendingWords) set(words string) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.words = &words
+}
+
+func (p *pendingWords) take() (string, bool) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	if p.words == nil {
+		return "", false
+	}
+	words := *p.words
+	p.words = nil
+	return words, true
+}
+
+// ReloadCurseWords replaces the curse words of this validator after the
+// current block. The list is rejected, and the current one kept, if it has
+// words the filter can't use. It is safe to call from any goroutine.
+func (app *ForumApp) ReloadCurseWords(curseWords string) error {
+	if err := ValidateCurseWords(curseWords); err != nil {
+		return fmt.Errorf("invalid curse_words: %w", err)
+	}
+	app.pendingWords.set(DedupWords(curseWords))
+	return nil
+}
+
+// ReloadConfig reads the curse words of the configuration file again. The
+// other parameters only change when the node restarts.
+func (app *ForumApp) ReloadConfig(file string) error {
+	cfg, err := LoadConfig(file)
+	if err != nil {
+		return err
+	}
+	return app.ReloadCurseWords(cfg.CurseWords)
+}
+
+// applyPendingWords switches to the curse words reloaded during the block
+func (app *ForumApp) applyPendingWords() {
+	words, ok := app.pendingWords.take()
+	if !ok {
+		return
+	}
+	added, removed := diffWords(app.CurseWords, words)
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+	app.CurseWords = words
+	app.logger.Info("reloaded curse words", "height", app.state.Height, "added", strings.Join(added, "|"), "removed", strings.Join(removed, "|"))
+}
+
+// diffWords returns the words of next that aren't in prev, and those of prev
+// that aren't in next, sorted
+func diffWords(prev, next string) (added, removed []string) {
+	prevSet, nextSet := wordSet(prev), wordSet(next)
+	for word := range nextSet {
+		if _, ok := prevSet[word]; !ok {
+			added = append(added, word)
+		}
+	}
+	for word := range prevSet {
+		if _, ok := nextSet[word]; !ok {
+			removed = append(removed, word)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+func wordSet(words string) map[string]struct{} {
+	set := make(map[string]struct{})
+	if words == "" {
+		return set
+	}
+	for _, word := range strings.Split(words, "|") {
+		set[word] = struct{}{}
+	}
+	return set
+}
+
+// reloadDelay is how long the file has to be left alone before it is read, so
+// a file being written isn't read half way
+const reloadDelay = 100 * time.Millisecond
+
+// WatchConfig reloads the curse words of the app whenever the configuration
+// file changes, until the returned function is called. Its directory is
+// watched rather than the file, so editors that replace the file are seen too.
+// Errors of a reload are passed to onError and the current words are kept.
+func WatchConfig(app *ForumApp, file string, onError func(error)) (func() error, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := watcher.Add(filepath.Dir(file)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	reload := func() {
+		if err := app.ReloadConfig(file); err != nil {
+			onError(err)
+		}
+	}
+	go func() {
+		var timer *time.Timer
+		defer func() {
+			if timer != nil {
+				timer.Stop()
+			}
+		}()
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != filepath.Clean(file) || event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+					continue
+				}
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(reloadDelay, reload)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				onError(err)
+			}
+		}
+	}()
+	return watcher.Close, nil
+}
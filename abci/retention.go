@@ -0,0 +1,56 @@
+package forum
+
+import (
+	"errors"
+)
+
+// RetentionConfig sets which blocks CometBFT keeps. The lowest height to keep
+// is returned to it as RetainHeight on every Commit, and the history of the
+// application state below it is pruned as well. Blocks are always kept from
+// the retain height up, CometBFT can't keep a sparse set of them.
+type RetentionConfig struct {
+	// KeepRecent is the number of recent blocks kept; 0 keeps every block
+	KeepRecent int64 `toml:"keep_recent"`
+	// KeepEvery rounds the retain height down to a multiple of it, so the
+	// oldest block kept is always at a multiple of KeepEvery and blocks are
+	// pruned KeepEvery at a time; 0 disables it
+	KeepEvery int64 `toml:"keep_every"`
+	// SnapshotInterval is the number of blocks between two state sync
+	// snapshots. Blocks are never pruned below the latest snapshot, which
+	// nodes syncing from it have to fetch. 0 means no snapshots are taken.
+	SnapshotInterval int64 `toml:"snapshot_interval"`
+}
+
+func (c RetentionConfig) Validate() error {
+	switch {
+	case c.KeepRecent < 0:
+		return errors.New("retention keep_recent can't be negative")
+	case c.KeepEvery < 0:
+		return errors.New("retention keep_every can't be negative")
+	case c.SnapshotInterval < 0:
+		return errors.New("retention snapshot_interval can't be negative")
+	default:
+		return nil
+	}
+}
+
+// retainHeight returns the lowest block to keep after committing height,
+// 0 to keep them all.
+func (c RetentionConfig) retainHeight(height int64) int64 {
+	if c.KeepRecent == 0 {
+		return 0
+	}
+	retain := height - c.KeepRecent + 1
+	if c.KeepEvery > 0 {
+		retain -= retain % c.KeepEvery
+	}
+	if c.SnapshotInterval > 0 {
+		if snapshot := height - height%c.SnapshotInterval; snapshot > 0 && snapshot < retain {
+			retain = snapshot
+		}
+	}
+	if retain <= 1 {
+		return 0
+	}
+	return retain
+}
@@ -0,0 +1,128 @@
+package forum
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	abciserver "github.com/cometbft/cometbft/abci/server"
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/cometbft/cometbft/libs/service"
+)
+
+// Transports of the ABCI server, for running the application in its own
+// process, apart from CometBFT
+const (
+	TransportSocket = "socket"
+	TransportGRPC   = "grpc"
+)
+
+// NewServer returns an ABCI server serving the application at addr, e.g.
+// "tcp://127.0.0.1:26658" or "unix://forum.sock", over the given transport.
+// CometBFT connects to it with proxy_app = addr and abci = transport in its
+// config.toml.
+func NewServer(app *ForumApp, transport string, addr string) (service.Service, error) {
+	switch transport {
+	case TransportSocket:
+		// The socket server already serializes the calls of its connections
+		return abciserver.NewSocketServer(addr, app), nil
+	case TransportGRPC:
+		return abciserver.NewGRPCServer(addr, &lockedApp{app: app}), nil
+	default:
+		return nil, fmt.Errorf("unknown ABCI transport %q", transport)
+	}
+}
+
+// lockedApp makes the calls to the application one at a time, as CometBFT
+// does with an application in its process. The gRPC server serves each
+// connection concurrently, and the application isn't safe for concurrent use.
+type lockedApp struct {
+	mtx sync.Mutex
+	app *ForumApp
+}
+
+var _ abci.Application = (*lockedApp)(nil)
+
+func (l *lockedApp) Info(ctx context.Context, req *abci.RequestInfo) (*abci.ResponseInfo, error) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.app.Info(ctx, req)
+}
+
+func (l *lockedApp) Query(ctx context.Context, req *abci.RequestQuery) (*abci.ResponseQuery, error) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.app.Query(ctx, req)
+}
+
+func (l *lockedApp) CheckTx(ctx context.Context, req *abci.RequestCheckTx) (*abci.ResponseCheckTx, error) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.app.CheckTx(ctx, req)
+}
+
+func (l *lockedApp) InitChain(ctx context.Context, req *abci.RequestInitChain) (*abci.ResponseInitChain, error) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.app.InitChain(ctx, req)
+}
+
+func (l *lockedApp) PrepareProposal(ctx context.Context, req *abci.RequestPrepareProposal) (*abci.ResponsePrepareProposal, error) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.app.PrepareProposal(ctx, req)
+}
+
+func (l *lockedApp) ProcessProposal(ctx context.Context, req *abci.RequestProcessProposal) (*abci.ResponseProcessProposal, error) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.app.ProcessProposal(ctx, req)
+}
+
+func (l *lockedApp) FinalizeBlock(ctx context.Context, req *abci.RequestFinalizeBlock) (*abci.ResponseFinalizeBlock, error) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.app.FinalizeBlock(ctx, req)
+}
+
+func (l *lockedApp) ExtendVote(ctx context.Context, req *abci.RequestExtendVote) (*abci.ResponseExtendVote, error) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.app.ExtendVote(ctx, req)
+}
+
+func (l *lockedApp) VerifyVoteExtension(ctx context.Context, req *abci.RequestVerifyVoteExtension) (*abci.ResponseVerifyVoteExtension, error) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.app.VerifyVoteExtension(ctx, req)
+}
+
+func (l *lockedApp) Commit(ctx context.Context, req *abci.RequestCommit) (*abci.ResponseCommit, error) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.app.Commit(ctx, req)
+}
+
+func (l *lockedApp) ListSnapshots(ctx context.Context, req *abci.RequestListSnapshots) (*abci.ResponseListSnapshots, error) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.app.ListSnapshots(ctx, req)
+}
+
+func (l *lockedApp) OfferSnapshot(ctx context.Context, req *abci.RequestOfferSnapshot) (*abci.ResponseOfferSnapshot, error) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.app.OfferSnapshot(ctx, req)
+}
+
+func (l *lockedApp) LoadSnapshotChunk(ctx context.Context, req *abci.RequestLoadSnapshotChunk) (*abci.ResponseLoadSnapshotChunk, error) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.app.LoadSnapshotChunk(ctx, req)
+}
+
+func (l *lockedApp) ApplySnapshotChunk(ctx context.Context, req *abci.RequestApplySnapshotChunk) (*abci.ResponseApplySnapshotChunk, error) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.app.ApplySnapshotChunk(ctx, req)
+}
@@ -3,52 +3,56 @@ package forum
 import (
 	"encoding/binary"
 	"encoding/json"
-	"fmt"
 
 	"github.com/alijnmerchant21/forum-updated/model"
-	"github.com/dgraph-io/badger/v2"
 )
 
 type AppState struct {
 	DB     *model.DB
-	Size   int64 `json:"size"`
-	Height int64 `json:"height"`
+	Size   int64  `json:"size"`
+	Height int64  `json:"height"`
+	Params Params `json:"params"`
+	// AppVersion is the version of the application the chain runs, which
+	// changes with upgrades
+	AppVersion uint64 `json:"app_version"`
 }
 
-var stateKey = "appstate"
-
 func (s AppState) Hash() []byte {
 	appHash := make([]byte, 8)
 	binary.PutVarint(appHash, s.Size)
 	return appHash
 }
 
-func loadState(db *model.DB) AppState {
-	var state AppState
-	state.DB = db
-	stateBytes, err := db.Get([]byte(stateKey))
-	if err != nil && err != badger.ErrKeyNotFound {
-		panic(err)
-	}
-	if len(stateBytes) == 0 {
-		return state
+// readAppState reads the state saved in a store, e.g. at a past height
+func readAppState(store model.KVStore) (AppState, error) {
+	state := AppState{Params: DefaultParams(), AppVersion: ApplicationVersion}
+	stateBytes, err := store.Get(model.AppStateKey)
+	if err != nil || len(stateBytes) == 0 {
+		return state, err
 	}
 	err = json.Unmarshal(stateBytes, &state)
-	fmt.Println("ST:", state)
-	state.DB = db
+	return state, err
+}
+
+// loadState reads the latest state. Like a past state, a state saved
+// before some parameters existed gets their defaults.
+func loadState(db *model.DB) AppState {
+	state, err := readAppState(db)
 	if err != nil {
 		panic(err)
 	}
+	state.DB = db
 	return state
 }
 
-func saveState(state *AppState) {
+// saveState stages the state in the given store; it is written to disk
+// together with the rest of the block.
+func saveState(store model.KVStore, state *AppState) {
 	stateBytes, err := json.Marshal(state)
 	if err != nil {
 		panic(err)
 	}
-	err = state.DB.Set([]byte(stateKey), stateBytes)
-	fmt.Println(state)
+	err = store.Set(model.AppStateKey, stateBytes)
 	if err != nil {
 		panic(err)
 	}
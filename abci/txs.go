@@ -0,0 +1,375 @@
+package forum
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/alijnmerchant21/forum-updated/bank"
+	"github.com/alijnmerchant21/forum-updated/model"
+	"github.com/alijnmerchant21/forum-updated/ratelimit"
+	abci "github.com/cometbft/cometbft/abci/types"
+)
+
+// txError is a failed transaction, reported to the client with its code
+type txError struct {
+	code uint32
+	log  string
+}
+
+func newTxError(code uint32, format string, args ...interface{}) *txError {
+	return &txError{code: code, log: fmt.Sprintf(format, args...)}
+}
+
+func (e *txError) checkTxResponse() *abci.ResponseCheckTx {
+	return &abci.ResponseCheckTx{Code: e.code, Log: e.log}
+}
+
+func (e *txError) execTxResult() *abci.ExecTxResult {
+	return &abci.ExecTxResult{Code: e.code, Log: e.log}
+}
+
+// authenticate checks the signature and nonce of a transaction against the
+// account of its sender, and returns that account. For a register
+// transaction it returns the account to be created.
+// In CheckTx a nonce ahead of the account is accepted, since earlier
+// transactions of the sender may still be in the mempool; in a block the
+// nonce has to match exactly.
+func authenticate(store model.KVStore, tx *model.Tx, exactNonce bool) (*model.User, *txError) {
+	if tx.Type == model.TxTypeRegister {
+		register, err := tx.ParseRegister()
+		if err != nil {
+			return nil, newTxError(CodeTypeInvalidTxFormat, "%v", err)
+		}
+		if !tx.VerifySignature(register.PubKey) {
+			return nil, newTxError(CodeTypeUnauthorized, "Invalid signature")
+		}
+		if tx.Nonce != 0 {
+			return nil, newTxError(CodeTypeBadNonce, "Register transactions must have nonce 0")
+		}
+		return &model.User{Name: tx.Sender, PubKey: register.PubKey, RecoveryKey: register.RecoveryKey}, nil
+	}
+
+	u, err := model.GetUser(store, tx.Sender)
+	if errors.Is(err, model.ErrNotFound) {
+		return nil, newTxError(CodeTypeUnknownUser, "User %s is not registered", tx.Sender)
+	}
+	if err != nil {
+		return nil, newTxError(CodeTypeEncodingError, "Failed to load sender: %v", err)
+	}
+	if u.Banned && tx.Type != model.TxTypeAppeal {
+		return nil, newTxError(CodeTypeBanned, "User is banned")
+	}
+	if !tx.VerifySignature(signingKey(u, tx)) {
+		return nil, newTxError(CodeTypeUnauthorized, "Invalid signature")
+	}
+	if tx.Nonce < u.Nonce || (exactNonce && tx.Nonce != u.Nonce) {
+		return nil, newTxError(CodeTypeBadNonce, "Invalid nonce %d, expected %d", tx.Nonce, u.Nonce)
+	}
+	return u, nil
+}
+
+// validate checks that an authenticated transaction can be executed on top of
+// the given store at the given height
+func (app *ForumApp) validate(store model.KVStore, tx *model.Tx, height int64) *txError {
+	switch tx.Type {
+	case model.TxTypeRegister:
+		if err := model.ValidateUserName(tx.Sender); err != nil {
+			return newTxError(CodeTypeInvalidTxFormat, "%v", err)
+		}
+		existing, err := store.Get(model.UserKey(tx.Sender))
+		if err != nil {
+			return newTxError(CodeTypeEncodingError, "Failed to load user")
+		}
+		if existing != nil {
+			return newTxError(CodeTypeUserExists, "User %s already exists", tx.Sender)
+		}
+		return nil
+	case model.TxTypePost:
+		post, err := tx.ParsePost()
+		if err != nil {
+			return newTxError(CodeTypeInvalidTxFormat, "%v", err)
+		}
+		remaining, err := ratelimit.Remaining(store, tx.Sender, height, app.state.Params.RateLimit)
+		if err != nil {
+			return newTxError(CodeTypeEncodingError, "Failed to load recent posts")
+		}
+		if remaining == 0 {
+			return newTxError(CodeTypeRateLimited, "Too many posts, try again in a few blocks")
+		}
+		if txErr := checkBoard(store, post.Board); txErr != nil {
+			return txErr
+		}
+		if post.ReplyTo != 0 {
+			if txErr := checkMessageID(store, post.ReplyTo); txErr != nil {
+				return txErr
+			}
+		}
+		return checkBalance(store, tx.Sender, app.state.Params.Fees.PostFeeFor(post.ToMessage(tx.Sender)))
+	case model.TxTypeTransfer:
+		transfer, err := tx.ParseTransfer()
+		if err != nil {
+			return newTxError(CodeTypeInvalidTxFormat, "%v", err)
+		}
+		return checkBalance(store, tx.Sender, transfer.Amount)
+	case model.TxTypeEdit:
+		edit, err := tx.ParseEdit()
+		if err != nil {
+			return newTxError(CodeTypeInvalidTxFormat, "%v", err)
+		}
+		return checkMessage(store, tx.Sender, edit.MessageID)
+	case model.TxTypeDelete:
+		del, err := tx.ParseDelete()
+		if err != nil {
+			return newTxError(CodeTypeInvalidTxFormat, "%v", err)
+		}
+		return checkMessage(store, tx.Sender, del.MessageID)
+	case model.TxTypeProfile:
+		if _, err := tx.ParseProfile(); err != nil {
+			return newTxError(CodeTypeInvalidTxFormat, "%v", err)
+		}
+		return nil
+	case model.TxTypeRotateKey, model.TxTypeSetRecovery, model.TxTypeRecover, model.TxTypeCancelRecovery:
+		return app.validateKeyTx(store, tx)
+	case model.TxTypeSetModerator, model.TxTypeWordList:
+		return validateAdminTx(store, tx)
+	case model.TxTypeProposeUpgrade, model.TxTypeApproveUpgrade:
+		return app.validateUpgradeTx(store, tx, height)
+	case model.TxTypeFlag, model.TxTypeBanUser, model.TxTypeAppeal, model.TxTypeResolveAppeal:
+		return validateModerationTx(store, tx)
+	default:
+		return newTxError(CodeTypeUnknownTx, "Unknown transaction type %q", tx.Type)
+	}
+}
+
+// checkBalance rejects transactions of senders that can't pay the amount
+func checkBalance(store model.KVStore, sender string, amount uint64) *txError {
+	if amount == 0 {
+		return nil
+	}
+	balance, err := bank.GetBalance(store, sender)
+	if err != nil {
+		return newTxError(CodeTypeEncodingError, "Failed to load balance")
+	}
+	if balance < amount {
+		return newTxError(CodeTypeInsufficientFunds, "Insufficient funds: balance is %d, needs %d", balance, amount)
+	}
+	return nil
+}
+
+// checkBoard checks that messages can be posted to the board
+func checkBoard(store model.KVStore, board string) *txError {
+	if board == "" {
+		board = model.DefaultBoard
+	}
+	ok, err := model.HasBoard(store, board)
+	if err != nil {
+		return newTxError(CodeTypeEncodingError, "Failed to load boards")
+	}
+	if !ok {
+		return newTxError(CodeTypeUnknownBoard, "Unknown board %s", board)
+	}
+	return nil
+}
+
+// checkMessage rejects edits and deletions of messages the sender did not post
+func checkMessage(store model.KVStore, sender string, id uint64) *txError {
+	ok, err := model.HasMessage(store, sender, id)
+	if err != nil {
+		return newTxError(CodeTypeEncodingError, "Failed to load messages")
+	}
+	if !ok {
+		return newTxError(CodeTypeUnknownMessage, "%s has no message %d", sender, id)
+	}
+	return nil
+}
+
+// checkTypedTx runs the checks of CheckTx against the committed state
+func (app *ForumApp) checkTypedTx(tx *model.Tx) *abci.ResponseCheckTx {
+	if _, txErr := authenticate(app.state.DB, tx, false); txErr != nil {
+		return txErr.checkTxResponse()
+	}
+	if txErr := app.validate(app.state.DB, tx, app.state.Height+1); txErr != nil {
+		return txErr.checkTxResponse()
+	}
+	return &abci.ResponseCheckTx{Code: CodeTypeOK}
+}
+
+// deliverTx executes the transaction at the given index of the block at the
+// given height, which is not a BanTx
+func (app *ForumApp) deliverTx(tx []byte, height int64, index int) *abci.ExecTxResult {
+	logger := app.logger.With("height", height, "index", index)
+	var result *abci.ExecTxResult
+	typed, err := model.ParseTx(tx)
+	if err != nil {
+		result = &abci.ExecTxResult{Code: CodeTypeEncodingError, Log: err.Error()}
+		if _, err := model.ParseMessage(tx); err == nil {
+			result = &abci.ExecTxResult{Code: CodeTypeUnauthorized, Log: "Posts must be signed"}
+		}
+	} else {
+		logger = logger.With("sender", typed.Sender, "type", typed.Type)
+		result = app.executeTx(typed, height)
+	}
+	if result.Code != CodeTypeOK {
+		logger.Info("failed transaction", "code", result.Code, "log", result.Log)
+	} else {
+		logger.Debug("executed transaction")
+	}
+	return result
+}
+
+// executeTx executes a transaction of the block at the given height
+func (app *ForumApp) executeTx(typed *model.Tx, height int64) *abci.ExecTxResult {
+	// ProcessProposal rejects blocks with invalid transactions, but blocks we
+	// sync from other nodes are not processed, so everything is checked again
+	u, txErr := authenticate(app.onGoingBlock, typed, true)
+	if txErr != nil {
+		return txErr.execTxResult()
+	}
+	if txErr := app.validate(app.onGoingBlock, typed, height); txErr != nil {
+		return txErr.execTxResult()
+	}
+
+	var events []abci.Event
+	switch typed.Type {
+	case model.TxTypeRegister:
+		events = []abci.Event{registerEvent(u)}
+	case model.TxTypePost:
+		events, txErr = app.deliverPost(typed, height)
+	case model.TxTypeTransfer:
+		events, txErr = app.deliverTransfer(typed)
+	case model.TxTypeEdit:
+		events, txErr = app.deliverEdit(typed)
+	case model.TxTypeDelete:
+		events, txErr = app.deliverDelete(typed)
+	case model.TxTypeProfile:
+		events, txErr = app.deliverProfile(typed)
+	case model.TxTypeRotateKey, model.TxTypeSetRecovery, model.TxTypeRecover, model.TxTypeCancelRecovery:
+		events, txErr = app.deliverKeyTx(typed, height)
+	case model.TxTypeSetModerator, model.TxTypeWordList:
+		events, txErr = app.deliverAdminTx(typed)
+	case model.TxTypeProposeUpgrade, model.TxTypeApproveUpgrade:
+		events, txErr = app.deliverUpgradeTx(typed, height)
+	case model.TxTypeFlag, model.TxTypeBanUser, model.TxTypeAppeal, model.TxTypeResolveAppeal:
+		events, txErr = app.deliverModerationTx(typed, height)
+	}
+	if txErr != nil {
+		return txErr.execTxResult()
+	}
+
+	// Executing the transaction may have changed the account
+	if typed.Type != model.TxTypeRegister {
+		var err error
+		u, err = model.GetUser(app.onGoingBlock, typed.Sender)
+		if err != nil {
+			panic(err)
+		}
+	}
+	u.Nonce++
+	if err := model.SetUser(app.onGoingBlock, u); err != nil {
+		panic(err)
+	}
+	// The data is not committed nor persisted until Commit is called
+	return &abci.ExecTxResult{Code: CodeTypeOK, Events: events}
+}
+
+func (app *ForumApp) deliverPost(tx *model.Tx, height int64) ([]abci.Event, *txError) {
+	post, err := tx.ParsePost()
+	if err != nil {
+		return nil, newTxError(CodeTypeInvalidTxFormat, "%v", err)
+	}
+	msg := post.ToMessage(tx.Sender)
+	// The fee is paid before anything is stored
+	if err := bank.Burn(app.onGoingBlock, msg.Sender, app.state.Params.Fees.PostFeeFor(msg)); err != nil {
+		panic(err)
+	}
+	// Add the message for this sender and append it to the chat history
+	msg.ID, err = model.NextMessageID(app.onGoingBlock)
+	if err != nil {
+		panic(err)
+	}
+	if msg.Board == "" {
+		msg.Board = model.DefaultBoard
+	}
+	if err := model.AddMessage(app.onGoingBlock, msg); err != nil {
+		panic(err)
+	}
+	if err := ratelimit.RecordPost(app.onGoingBlock, msg.Sender, height, app.state.Params.RateLimit); err != nil {
+		panic(err)
+	}
+	u, err := model.GetUser(app.onGoingBlock, msg.Sender)
+	if err != nil {
+		panic(err)
+	}
+	if err := model.RecordPost(app.onGoingBlock, u, height); err != nil {
+		panic(err)
+	}
+	if err := model.SetUser(app.onGoingBlock, u); err != nil {
+		panic(err)
+	}
+	app.state.Size++
+	return []abci.Event{postEvent(msg)}, nil
+}
+
+func (app *ForumApp) deliverTransfer(tx *model.Tx) ([]abci.Event, *txError) {
+	transfer, err := tx.ParseTransfer()
+	if err != nil {
+		return nil, newTxError(CodeTypeInvalidTxFormat, "%v", err)
+	}
+	err = bank.Transfer(app.onGoingBlock, tx.Sender, transfer.To, transfer.Amount)
+	if errors.Is(err, bank.ErrBalanceOverflow) {
+		return nil, newTxError(CodeTypeInvalidTxFormat, "%v", err)
+	}
+	if err != nil {
+		panic(err)
+	}
+	return []abci.Event{transferEvent(tx.Sender, *transfer)}, nil
+}
+
+func (app *ForumApp) deliverProfile(tx *model.Tx) ([]abci.Event, *txError) {
+	profile, err := tx.ParseProfile()
+	if err != nil {
+		return nil, newTxError(CodeTypeInvalidTxFormat, "%v", err)
+	}
+	if err := model.SetProfile(app.onGoingBlock, tx.Sender, profile); err != nil {
+		panic(err)
+	}
+	return []abci.Event{userEvent(EventTypeProfile, tx.Sender)}, nil
+}
+
+func (app *ForumApp) deliverEdit(tx *model.Tx) ([]abci.Event, *txError) {
+	edit, err := tx.ParseEdit()
+	if err != nil {
+		return nil, newTxError(CodeTypeInvalidTxFormat, "%v", err)
+	}
+	if err := model.EditMessage(app.onGoingBlock, tx.Sender, edit.MessageID, edit.Message); err != nil {
+		panic(err)
+	}
+	if err := app.updateUser(tx.Sender, func(u *model.User) { u.Edits++ }); err != nil {
+		panic(err)
+	}
+	return []abci.Event{messageEvent(EventTypeEdit, tx.Sender, edit.MessageID)}, nil
+}
+
+func (app *ForumApp) deliverDelete(tx *model.Tx) ([]abci.Event, *txError) {
+	del, err := tx.ParseDelete()
+	if err != nil {
+		return nil, newTxError(CodeTypeInvalidTxFormat, "%v", err)
+	}
+	if err := model.DeleteMessage(app.onGoingBlock, tx.Sender, del.MessageID); err != nil {
+		panic(err)
+	}
+	if err := app.updateUser(tx.Sender, func(u *model.User) { u.Deletions++ }); err != nil {
+		panic(err)
+	}
+	return []abci.Event{messageEvent(EventTypeDelete, tx.Sender, del.MessageID)}, nil
+}
+
+// updateUser changes the stored account of a user in the ongoing block
+func (app *ForumApp) updateUser(name string, update func(*model.User)) error {
+	u, err := model.GetUser(app.onGoingBlock, name)
+	if err != nil {
+		return err
+	}
+	update(u)
+	return model.SetUser(app.onGoingBlock, u)
+}
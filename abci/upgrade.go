@@ -0,0 +1,209 @@
+package forum
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/alijnmerchant21/forum-updated/model"
+	abci "github.com/cometbft/cometbft/abci/types"
+	cmtproto "github.com/cometbft/cometbft/proto/tendermint/types"
+)
+
+// UpgradeHandler runs in the block at the height of an upgrade plan, before
+// its transactions, to bring the state to the new application version. The
+// writes go to the store of the block.
+type UpgradeHandler func(store model.KVStore, plan model.UpgradePlan) error
+
+var upgradeHandlers = make(map[uint64]UpgradeHandler)
+
+// RegisterUpgradeHandler registers the handler that moves the state to the
+// given application version. A binary can carry out the upgrades to the
+// versions it registers a handler for, and to the ones up to
+// ApplicationVersion, which need no handler.
+func RegisterUpgradeHandler(version uint64, handler UpgradeHandler) {
+	if _, ok := upgradeHandlers[version]; ok {
+		panic(fmt.Sprintf("upgrade handler for version %d registered twice", version))
+	}
+	upgradeHandlers[version] = handler
+}
+
+// ErrUpgradeNeeded is returned by FinalizeBlock at the height of an upgrade
+// this binary can't carry out. The block is not executed, so restarting with
+// the new binary executes it again.
+var ErrUpgradeNeeded = errors.New("upgrade needed")
+
+func supportsVersion(version uint64) bool {
+	_, ok := upgradeHandlers[version]
+	return ok || version <= ApplicationVersion
+}
+
+// validateUpgradeTx checks the transactions that propose and approve upgrades
+func (app *ForumApp) validateUpgradeTx(store model.KVStore, tx *model.Tx, height int64) *txError {
+	sender, err := model.GetUser(store, tx.Sender)
+	if err != nil {
+		return newTxError(CodeTypeEncodingError, "Failed to load sender")
+	}
+	if !sender.Moderator {
+		return newTxError(CodeTypeUnauthorized, "Only moderators can vote on upgrades")
+	}
+	if app.state.Params.Upgrade.Quorum == 0 {
+		return newTxError(CodeTypeInvalidUpgrade, "Upgrades are disabled")
+	}
+	switch tx.Type {
+	case model.TxTypeProposeUpgrade:
+		propose, err := tx.ParseProposeUpgrade()
+		if err != nil {
+			return newTxError(CodeTypeInvalidTxFormat, "%v", err)
+		}
+		if propose.Plan.Version <= app.state.AppVersion {
+			return newTxError(CodeTypeInvalidUpgrade, "The chain already runs version %d", app.state.AppVersion)
+		}
+		if propose.Plan.Height <= height {
+			return newTxError(CodeTypeInvalidUpgrade, "The upgrade height must be after %d", height)
+		}
+	case model.TxTypeApproveUpgrade:
+		approve, err := tx.ParseApproveUpgrade()
+		if err != nil {
+			return newTxError(CodeTypeInvalidTxFormat, "%v", err)
+		}
+		proposals, err := model.UpgradeProposals(store)
+		if err != nil {
+			return newTxError(CodeTypeEncodingError, "Failed to load upgrade proposals")
+		}
+		proposal := findProposal(proposals, approve.ProposalID)
+		if proposal == nil || proposal.Status != model.ProposalVoting {
+			return newTxError(CodeTypeInvalidUpgrade, "No upgrade proposal %d is open for approval", approve.ProposalID)
+		}
+		for _, m := range proposal.Approvals {
+			if m == tx.Sender {
+				return newTxError(CodeTypeInvalidUpgrade, "%s already approved proposal %d", tx.Sender, approve.ProposalID)
+			}
+		}
+	}
+	return nil
+}
+
+func findProposal(proposals []model.UpgradeProposal, id uint64) *model.UpgradeProposal {
+	i := sort.Search(len(proposals), func(i int) bool { return proposals[i].ID >= id })
+	if i < len(proposals) && proposals[i].ID == id {
+		return &proposals[i]
+	}
+	return nil
+}
+
+// deliverUpgradeTx executes a transaction validated by validateUpgradeTx.
+// A proposal approved by enough moderators becomes the scheduled upgrade,
+// replacing the one scheduled before.
+func (app *ForumApp) deliverUpgradeTx(tx *model.Tx, height int64) ([]abci.Event, *txError) {
+	proposals, err := model.UpgradeProposals(app.onGoingBlock)
+	if err != nil {
+		panic(err)
+	}
+	var proposal *model.UpgradeProposal
+	var events []abci.Event
+	switch tx.Type {
+	case model.TxTypeProposeUpgrade:
+		propose, err := tx.ParseProposeUpgrade()
+		if err != nil {
+			return nil, newTxError(CodeTypeInvalidTxFormat, "%v", err)
+		}
+		proposals = append(proposals, model.UpgradeProposal{
+			ID:           uint64(len(proposals)) + 1,
+			Proposer:     tx.Sender,
+			Plan:         propose.Plan,
+			SubmitHeight: height,
+			Approvals:    []string{tx.Sender},
+			Status:       model.ProposalVoting,
+		})
+		proposal = &proposals[len(proposals)-1]
+		events = append(events, upgradeEvent(EventTypeUpgradeProposal, tx.Sender, *proposal))
+	case model.TxTypeApproveUpgrade:
+		approve, err := tx.ParseApproveUpgrade()
+		if err != nil {
+			return nil, newTxError(CodeTypeInvalidTxFormat, "%v", err)
+		}
+		proposal = findProposal(proposals, approve.ProposalID)
+		proposal.Approve(tx.Sender)
+		events = append(events, upgradeEvent(EventTypeUpgradeApproval, tx.Sender, *proposal))
+	}
+	if len(proposal.Approvals) >= app.state.Params.Upgrade.Quorum {
+		if scheduled := model.ScheduledUpgrade(proposals); scheduled != nil {
+			scheduled.Status = model.ProposalSuperseded
+		}
+		proposal.Status = model.ProposalScheduled
+		events = append(events, upgradeEvent(EventTypeUpgradeScheduled, tx.Sender, *proposal))
+	}
+	if err := model.SetUpgradeProposals(app.onGoingBlock, proposals); err != nil {
+		panic(err)
+	}
+	return events, nil
+}
+
+// expireUpgradeProposals closes the proposals that were not approved within
+// the voting period, or before the block preceding their height, at the end
+// of the block at height
+func (app *ForumApp) expireUpgradeProposals(height int64) {
+	proposals, err := model.UpgradeProposals(app.onGoingBlock)
+	if err != nil {
+		panic(err)
+	}
+	expired := false
+	for i, p := range proposals {
+		if p.Status != model.ProposalVoting {
+			continue
+		}
+		if height >= p.SubmitHeight+app.state.Params.Upgrade.VotingPeriod || p.Plan.Height <= height+1 {
+			proposals[i].Status = model.ProposalExpired
+			expired = true
+		}
+	}
+	if expired {
+		if err := model.SetUpgradeProposals(app.onGoingBlock, proposals); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// applyUpgrade carries out the upgrade scheduled at height, if there is one.
+// It returns ErrUpgradeNeeded if this binary doesn't support the new version.
+// Otherwise the upgrade handler and the pending data migrations run in the
+// block, and the chain moves to the new version.
+func (app *ForumApp) applyUpgrade(height int64) (*abci.Event, *cmtproto.ConsensusParams, error) {
+	proposals, err := model.UpgradeProposals(app.onGoingBlock)
+	if err != nil {
+		panic(err)
+	}
+	scheduled := model.ScheduledUpgrade(proposals)
+	if scheduled == nil || scheduled.Plan.Height != height {
+		return nil, nil, nil
+	}
+	plan := scheduled.Plan
+	if !supportsVersion(plan.Version) {
+		app.logger.Error("UPGRADE NEEDED", "name", plan.Name, "height", height,
+			"version", ApplicationVersion, "chain_version", plan.Version, "info", plan.Info)
+		return nil, nil, fmt.Errorf("%w: %q at height %d, to application version %d", ErrUpgradeNeeded, plan.Name, height, plan.Version)
+	}
+	if handler, ok := upgradeHandlers[plan.Version]; ok {
+		if err := handler(app.onGoingBlock, plan); err != nil {
+			panic(fmt.Errorf("upgrade %q failed: %w", plan.Name, err))
+		}
+	}
+	applied, err := model.Migrate(app.onGoingBlock)
+	if err != nil {
+		panic(err)
+	}
+	for _, m := range applied {
+		app.logger.Info("migrated data", "height", height, "version", m.Version, "description", m.Description)
+	}
+	scheduled.Status = model.ProposalDone
+	if err := model.SetUpgradeProposals(app.onGoingBlock, proposals); err != nil {
+		panic(err)
+	}
+	app.state.AppVersion = plan.Version
+	app.logger.Info("upgraded application", "height", height, "version", plan.Version, "name", plan.Name)
+	event := upgradeEvent(EventTypeUpgrade, scheduled.Proposer, *scheduled)
+	// The new version goes into the headers of the next blocks
+	update := &cmtproto.ConsensusParams{Version: &cmtproto.VersionParams{App: plan.Version}}
+	return &event, update, nil
+}
@@ -6,16 +6,25 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"unicode"
 
 	"github.com/alijnmerchant21/forum-updated/model"
 	"github.com/cometbft/cometbft/abci/types"
-	"github.com/cometbft/cometbft/crypto/ed25519"
 	cryptoencoding "github.com/cometbft/cometbft/crypto/encoding"
-	"github.com/dgraph-io/badger/v3"
 )
 
 func isBanTx(tx []byte) bool {
-	return strings.Contains(string(tx), "username")
+	var banTx model.BanTx
+	return json.Unmarshal(tx, &banTx) == nil && banTx.UserName != ""
+}
+
+// banTxBytes returns the ban transaction a proposer adds for a user that used a curse word
+func banTxBytes(user string) []byte {
+	resultBytes, err := json.Marshal(model.BanTx{UserName: user, Reason: BanReasonCurseWord})
+	if err != nil {
+		panic(fmt.Errorf("ban transaction failed to marshal in prepareProposal"))
+	}
+	return resultBytes
 }
 
 func (app *ForumApp) getValidators() (validators []types.ValidatorUpdate) {
@@ -27,61 +36,161 @@ func (app *ForumApp) getValidators() (validators []types.ValidatorUpdate) {
 	return
 }
 
-func (app *ForumApp) updateValidator(v types.ValidatorUpdate) {
+func (app *ForumApp) updateValidator(store model.KVStore, v types.ValidatorUpdate) {
 	pubkey, err := cryptoencoding.PubKeyFromProto(v.PubKey)
 	if err != nil {
 		panic(fmt.Errorf("can't decode public key: %w", err))
 	}
-	key := []byte("val" + string(pubkey.Bytes()))
+	key := model.ValidatorKey(pubkey.Bytes())
 
 	// add or update validator
 	value := bytes.NewBuffer(make([]byte, 0))
 	if err := types.WriteMessage(&v, value); err != nil {
 		panic(err)
 	}
-	if err = app.state.DB.Set(key, value.Bytes()); err != nil {
+	if err = store.Set(key, value.Bytes()); err != nil {
 		panic(err)
 	}
 	app.valAddrToPubKeyMap[string(pubkey.Address())] = v.PubKey
 
 }
 
-func IsCurseWord(word string, curseWords string) bool {
-	// Define your list of curse words here
-	// For example:
-	return strings.Contains(curseWords, word)
+// IsCurseWord reports whether the text contains one of the curse words, a
+// '|' separated list. Words are compared without case or punctuation, so
+// "Bad!" matches "bad" but "badge" doesn't. A curse word of several words
+// matches them in a row.
+func IsCurseWord(text string, curseWords string) bool {
+	words := splitWords(text)
+	set := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		set[w] = struct{}{}
+	}
+	joined := " " + strings.Join(words, " ") + " "
+	for _, curseWord := range strings.Split(curseWords, "|") {
+		curse := splitWords(curseWord)
+		switch len(curse) {
+		case 0:
+			continue
+		case 1:
+			if _, ok := set[curse[0]]; ok {
+				return true
+			}
+		default:
+			if strings.Contains(joined, " "+strings.Join(curse, " ")+" ") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// splitWords returns the lowercase words of a text, without punctuation
+func splitWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// moderatedText returns the text of a transaction that goes through the word filter
+func moderatedText(tx *model.Tx) []string {
+	switch tx.Type {
+	case model.TxTypePost:
+		if post, err := tx.ParsePost(); err == nil {
+			return []string{post.Message}
+		}
+	case model.TxTypeEdit:
+		if edit, err := tx.ParseEdit(); err == nil {
+			return []string{edit.Message}
+		}
+	case model.TxTypeProfile:
+		if profile, err := tx.ParseProfile(); err == nil {
+			return profile.Text()
+		}
+	case model.TxTypeFlag:
+		if flag, err := tx.ParseFlag(); err == nil && flag.Reason != "" {
+			return []string{flag.Reason}
+		}
+	case model.TxTypeBanUser:
+		if ban, err := tx.ParseBanUser(); err == nil && ban.Reason != "" {
+			return []string{ban.Reason}
+		}
+	case model.TxTypeAppeal:
+		if appeal, err := tx.ParseAppeal(); err == nil {
+			return []string{appeal.Reason}
+		}
+	}
+	return nil
+}
+
+func containsCurseWord(text []string, curseWords string) bool {
+	for _, t := range text {
+		if IsCurseWord(t, curseWords) {
+			return true
+		}
+	}
+	return false
 }
 
 const (
-	CodeTypeOK              uint32 = 0
-	CodeTypeEncodingError   uint32 = 1
-	CodeTypeInvalidTxFormat uint32 = 2
-	CodeTypeBanned          uint32 = 3
+	CodeTypeOK                uint32 = 0
+	CodeTypeEncodingError     uint32 = 1
+	CodeTypeInvalidTxFormat   uint32 = 2
+	CodeTypeBanned            uint32 = 3
+	CodeTypeSenderLimit       uint32 = 4
+	CodeTypeInsufficientFunds uint32 = 5
+	CodeTypeUnknownTx         uint32 = 6
+	CodeTypeRateLimited       uint32 = 7
+	CodeTypeUnknownUser       uint32 = 8
+	CodeTypeUnauthorized      uint32 = 9
+	CodeTypeBadNonce          uint32 = 10
+	CodeTypeUserExists        uint32 = 11
+	CodeTypeNoRecovery        uint32 = 12
+	CodeTypeUnknownMessage    uint32 = 13
+	CodeTypeUnknownBoard      uint32 = 14
+	CodeTypeInvalidUpgrade    uint32 = 15
+	CodeTypeDuplicate         uint32 = 16
+	CodeTypeNoAppeal          uint32 = 17
+	CodeTypeRecoveryPending   uint32 = 18
+	CodeTypeNotFound          uint32 = 19
+	CodeTypeInvalidQuery      uint32 = 20
+	CodeTypeHeightUnavailable uint32 = 21
 )
 
-func UpdateOrSetUser(db *model.DB, uname string, toBan bool, txn *badger.Txn) error {
+// UpdateOrSetUser sets the ban status of a user. Users are only created by
+// registering, so an unknown user is stored without a key; this keeps the
+// name from being registered by the banned user later.
+func UpdateOrSetUser(store model.KVStore, uname string, toBan bool) error {
 	var u *model.User
-	u, err := db.FindUserByName(uname)
-	if errors.Is(err, badger.ErrKeyNotFound) {
+	u, err := model.GetUser(store, uname)
+	if errors.Is(err, model.ErrNotFound) {
 		u = new(model.User)
 		u.Name = uname
-		u.PubKey = ed25519.GenPrivKey().PubKey().Bytes()
 		u.Banned = toBan
+		if toBan {
+			u.Strikes++
+		}
 	} else {
 		if err == nil {
+			if toBan && !u.Banned {
+				u.Strikes++
+			}
 			u.Banned = toBan
 		} else {
 			err = fmt.Errorf("not able to process user")
 			return err
 		}
 	}
-	userBytes, err := json.Marshal(u)
+	return model.SetUser(store, u)
+
+}
+
+// wordList returns the word list of the chain, '|' separated
+func (app *ForumApp) wordList() string {
+	words, err := model.GetWordList(app.state.DB)
 	if err != nil {
-		fmt.Println("Error marshalling user")
-		return err
+		panic(err)
 	}
-	return txn.Set([]byte(uname), userBytes)
-
+	return strings.Join(words, "|")
 }
 
 func DedupWords(inWords string) string {
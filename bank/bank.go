@@ -0,0 +1,85 @@
+package bank
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/alijnmerchant21/forum-updated/model"
+)
+
+var (
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrBalanceOverflow   = errors.New("balance overflows")
+)
+
+// FeeParams define what posting a message costs. They are part of the
+// consensus state, so every validator charges the same fees.
+type FeeParams struct {
+	// PostFee is charged for every post
+	PostFee uint64 `json:"post_fee"`
+	// FeePerByte is charged for every byte of the message on top of PostFee
+	FeePerByte uint64 `json:"fee_per_byte"`
+}
+
+// PostFeeFor returns the fee for posting the given message
+func (p FeeParams) PostFeeFor(msg model.Message) uint64 {
+	return p.PostFee + p.FeePerByte*uint64(len(msg.Message))
+}
+
+// GetBalance returns the balance of a user; users that never received
+// anything have a balance of 0.
+func GetBalance(store model.KVStore, user string) (uint64, error) {
+	balanceBytes, err := store.Get(model.BalanceKey(user))
+	if err != nil {
+		return 0, err
+	}
+	if len(balanceBytes) != 8 {
+		return 0, nil
+	}
+	return binary.BigEndian.Uint64(balanceBytes), nil
+}
+
+func SetBalance(store model.KVStore, user string, amount uint64) error {
+	balanceBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(balanceBytes, amount)
+	return store.Set(model.BalanceKey(user), balanceBytes)
+}
+
+// Transfer moves amount from one user to another. Both balances are checked
+// before either is written, so a failed transfer changes nothing.
+func Transfer(store model.KVStore, from, to string, amount uint64) error {
+	fromBalance, err := GetBalance(store, from)
+	if err != nil {
+		return err
+	}
+	if fromBalance < amount {
+		return fmt.Errorf("%w: %s has %d, needs %d", ErrInsufficientFunds, from, fromBalance, amount)
+	}
+	if from == to {
+		return nil
+	}
+	toBalance, err := GetBalance(store, to)
+	if err != nil {
+		return err
+	}
+	if toBalance+amount < toBalance {
+		return fmt.Errorf("%w: %s", ErrBalanceOverflow, to)
+	}
+	if err := SetBalance(store, from, fromBalance-amount); err != nil {
+		return err
+	}
+	return SetBalance(store, to, toBalance+amount)
+}
+
+// Burn removes amount from the balance of a user. Fees are burned.
+func Burn(store model.KVStore, user string, amount uint64) error {
+	balance, err := GetBalance(store, user)
+	if err != nil {
+		return err
+	}
+	if balance < amount {
+		return fmt.Errorf("%w: %s has %d, needs %d", ErrInsufficientFunds, user, balance, amount)
+	}
+	return SetBalance(store, user, balance-amount)
+}
@@ -0,0 +1,212 @@
+// Package client is a Go API to the forum. It builds and signs the
+// transactions of an account, keeps track of its nonces, broadcasts them
+// through the RPC of a CometBFT node and decodes the results of queries.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	nm "github.com/cometbft/cometbft/node"
+	rpcclient "github.com/cometbft/cometbft/rpc/client"
+	rpchttp "github.com/cometbft/cometbft/rpc/client/http"
+	rpclocal "github.com/cometbft/cometbft/rpc/client/local"
+
+	forum "github.com/alijnmerchant21/forum-updated/abci"
+	"github.com/alijnmerchant21/forum-updated/keyring"
+	"github.com/alijnmerchant21/forum-updated/model"
+)
+
+// BroadcastMode is how long sending a transaction waits, as the
+// broadcast_tx_* endpoints of CometBFT
+type BroadcastMode string
+
+const (
+	// BroadcastAsync returns as soon as the transaction is sent
+	BroadcastAsync BroadcastMode = "async"
+	// BroadcastSync waits for CheckTx
+	BroadcastSync BroadcastMode = "sync"
+	// BroadcastCommit waits for the transaction to be in a block
+	BroadcastCommit BroadcastMode = "commit"
+)
+
+// ErrNoKey is returned when a client without a key sends a transaction
+var ErrNoKey = errors.New("the client has no key to sign transactions with")
+
+// TxError is a transaction the application rejected, in CheckTx or in its
+// block. Code is one of the CodeType constants of the abci package.
+type TxError struct {
+	Code uint32
+	Log  string
+}
+
+func (e *TxError) Error() string {
+	return fmt.Sprintf("transaction failed with code %d: %s", e.Code, e.Log)
+}
+
+// QueryError is a query the application could not answer. Code is one of
+// the CodeType constants of the abci package, e.g. CodeTypeNotFound.
+type QueryError struct {
+	Path string
+	Code uint32
+	Log  string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("query %s failed with code %d: %s", e.Path, e.Code, e.Log)
+}
+
+// TxResult is the outcome of a broadcast transaction. Height and Events are
+// only set in the commit mode.
+type TxResult struct {
+	Hash   []byte
+	Height int64
+	Events []abci.Event
+}
+
+// Client talks to a forum node, signing transactions with the key of one account
+type Client struct {
+	rpc  rpcclient.Client
+	key  *keyring.Key
+	mode BroadcastMode
+
+	mtx sync.Mutex
+	// nonce is the nonce of the next transaction, once loaded from the account
+	nonce *uint64
+}
+
+// New returns a client using the RPC client. The key may be nil for a
+// client that only queries.
+func New(rpc rpcclient.Client, key *keyring.Key) *Client {
+	return &Client{rpc: rpc, key: key, mode: BroadcastSync}
+}
+
+// NewHTTP returns a client of the node at the RPC endpoint, e.g. http://127.0.0.1:26657
+func NewHTTP(endpoint string, key *keyring.Key) (*Client, error) {
+	rpc, err := rpchttp.New(endpoint, "/websocket")
+	if err != nil {
+		return nil, err
+	}
+	return New(rpc, key), nil
+}
+
+// NewLocal returns a client of a node running in this process, e.g. in tests
+func NewLocal(n *nm.Node, key *keyring.Key) *Client {
+	return New(rpclocal.New(n), key)
+}
+
+// SetBroadcastMode sets how long sending a transaction waits; BroadcastSync by default
+func (c *Client) SetBroadcastMode(mode BroadcastMode) {
+	c.mode = mode
+}
+
+// SetNonce sets the nonce of the next transaction, instead of the one of the
+// account; later transactions follow it
+func (c *Client) SetNonce(nonce uint64) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.nonce = &nonce
+}
+
+// Send signs a transaction of the account with its next nonce and broadcasts it
+func (c *Client) Send(ctx context.Context, txType string, data interface{}) (*TxResult, error) {
+	if c.key == nil {
+		return nil, ErrNoKey
+	}
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	nonce, err := c.nextNonce(ctx, txType)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := model.NewTx(txType, c.key.Name, nonce, data)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Sign(c.key.PrivKey); err != nil {
+		return nil, err
+	}
+	txBytes, err := tx.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := c.broadcast(ctx, txBytes)
+	if err != nil {
+		// The nonce may or may not have been used, so it is loaded again
+		// from the account before the next transaction
+		c.nonce = nil
+		return nil, err
+	}
+	nonce++
+	c.nonce = &nonce
+	return result, nil
+}
+
+// nextNonce returns the nonce of the next transaction, loading it from the
+// account the first time
+func (c *Client) nextNonce(ctx context.Context, txType string) (uint64, error) {
+	if c.nonce != nil {
+		return *c.nonce, nil
+	}
+	if txType == model.TxTypeRegister {
+		return 0, nil
+	}
+	u, err := c.GetUser(ctx, c.key.Name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load the account of %s: %w", c.key.Name, err)
+	}
+	return u.Nonce, nil
+}
+
+func (c *Client) broadcast(ctx context.Context, tx []byte) (*TxResult, error) {
+	switch c.mode {
+	case BroadcastAsync:
+		res, err := c.rpc.BroadcastTxAsync(ctx, tx)
+		if err != nil {
+			return nil, err
+		}
+		return &TxResult{Hash: res.Hash}, nil
+	case BroadcastSync:
+		res, err := c.rpc.BroadcastTxSync(ctx, tx)
+		if err != nil {
+			return nil, err
+		}
+		if res.Code != forum.CodeTypeOK {
+			return nil, &TxError{Code: res.Code, Log: res.Log}
+		}
+		return &TxResult{Hash: res.Hash}, nil
+	case BroadcastCommit:
+		res, err := c.rpc.BroadcastTxCommit(ctx, tx)
+		if err != nil {
+			return nil, err
+		}
+		if res.CheckTx.Code != forum.CodeTypeOK {
+			return nil, &TxError{Code: res.CheckTx.Code, Log: res.CheckTx.Log}
+		}
+		if res.TxResult.Code != forum.CodeTypeOK {
+			return nil, &TxError{Code: res.TxResult.Code, Log: res.TxResult.Log}
+		}
+		return &TxResult{Hash: res.Hash, Height: res.Height, Events: res.TxResult.Events}, nil
+	default:
+		return nil, fmt.Errorf("unknown broadcast mode %q", c.mode)
+	}
+}
+
+// Query sends a query at a height, 0 for the latest one, and decodes its
+// JSON result
+func (c *Client) Query(ctx context.Context, path string, data string, height int64, result interface{}) error {
+	res, err := c.rpc.ABCIQueryWithOptions(ctx, path, []byte(data), rpcclient.ABCIQueryOptions{Height: height})
+	if err != nil {
+		return err
+	}
+	if !res.Response.IsOK() {
+		return &QueryError{Path: path, Code: res.Response.Code, Log: res.Response.Log}
+	}
+	return json.Unmarshal(res.Response.Value, result)
+}
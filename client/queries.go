@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	forum "github.com/alijnmerchant21/forum-updated/abci"
+	"github.com/alijnmerchant21/forum-updated/model"
+)
+
+// GetUser returns the account of a user
+func (c *Client) GetUser(ctx context.Context, name string) (*model.User, error) {
+	var u model.User
+	if err := c.Query(ctx, forum.QueryPathUser, name, 0, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// GetMessages returns the messages posted by a user
+func (c *Client) GetMessages(ctx context.Context, sender string) ([]model.Message, error) {
+	var messages []model.Message
+	err := c.Query(ctx, forum.QueryPathMessages, sender, 0, &messages)
+	return messages, err
+}
+
+// History returns a page of the chat history, oldest entries first. Pages
+// start at 1 and hold pageSize entries, the default size of the node if 0.
+func (c *Client) History(ctx context.Context, page int, pageSize int) (*model.HistoryPage, error) {
+	data := strconv.Itoa(page)
+	if pageSize > 0 {
+		data = fmt.Sprintf("%d/%d", page, pageSize)
+	}
+	var result model.HistoryPage
+	if err := c.Query(ctx, forum.QueryPathHistory, data, 0, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetProfile returns the profile of a user
+func (c *Client) GetProfile(ctx context.Context, name string) (*model.Profile, error) {
+	var profile model.Profile
+	if err := c.Query(ctx, forum.QueryPathProfile, name, 0, &profile); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// Balance returns the token balance of a user
+func (c *Client) Balance(ctx context.Context, name string) (uint64, error) {
+	var balance uint64
+	err := c.Query(ctx, forum.QueryPathBalance, name, 0, &balance)
+	return balance, err
+}
+
+// Flags returns the flags of a message
+func (c *Client) Flags(ctx context.Context, messageID uint64) ([]model.Flag, error) {
+	var flags []model.Flag
+	err := c.Query(ctx, forum.QueryPathFlags, strconv.FormatUint(messageID, 10), 0, &flags)
+	return flags, err
+}
+
+// Bans returns the names of the banned users
+func (c *Client) Bans(ctx context.Context) ([]string, error) {
+	var names []string
+	err := c.Query(ctx, forum.QueryPathBans, "", 0, &names)
+	return names, err
+}
+
+// Moderators returns the names of the moderators
+func (c *Client) Moderators(ctx context.Context) ([]string, error) {
+	var names []string
+	err := c.Query(ctx, forum.QueryPathModerators, "", 0, &names)
+	return names, err
+}
+
+// Params returns the consensus parameters of the forum
+func (c *Client) Params(ctx context.Context) (*forum.Params, error) {
+	var params forum.Params
+	if err := c.Query(ctx, forum.QueryPathParams, "", 0, &params); err != nil {
+		return nil, err
+	}
+	return &params, nil
+}
+
+// Proposals returns the upgrade proposals
+func (c *Client) Proposals(ctx context.Context) ([]model.UpgradeProposal, error) {
+	var proposals []model.UpgradeProposal
+	err := c.Query(ctx, forum.QueryPathProposals, "", 0, &proposals)
+	return proposals, err
+}
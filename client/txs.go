@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+
+	"github.com/alijnmerchant21/forum-updated/model"
+)
+
+// Register creates the account of the key of the client. The recovery key is optional.
+func (c *Client) Register(ctx context.Context, recoveryKey []byte) (*TxResult, error) {
+	if c.key == nil {
+		return nil, ErrNoKey
+	}
+	return c.Send(ctx, model.TxTypeRegister, model.RegisterTx{PubKey: c.key.PubKey(), RecoveryKey: recoveryKey})
+}
+
+// Post posts a message on a board, the default board if empty
+func (c *Client) Post(ctx context.Context, message string, board string) (*TxResult, error) {
+	return c.Send(ctx, model.TxTypePost, model.PostTx{Message: message, Board: board})
+}
+
+// Reply posts a reply to a message
+func (c *Client) Reply(ctx context.Context, messageID uint64, message string) (*TxResult, error) {
+	return c.Send(ctx, model.TxTypePost, model.PostTx{Message: message, ReplyTo: messageID})
+}
+
+// Edit replaces the text of a message of the account
+func (c *Client) Edit(ctx context.Context, messageID uint64, message string) (*TxResult, error) {
+	return c.Send(ctx, model.TxTypeEdit, model.EditTx{MessageID: messageID, Message: message})
+}
+
+// Delete deletes a message of the account
+func (c *Client) Delete(ctx context.Context, messageID uint64) (*TxResult, error) {
+	return c.Send(ctx, model.TxTypeDelete, model.DeleteTx{MessageID: messageID})
+}
+
+// Flag reports a message as inappropriate
+func (c *Client) Flag(ctx context.Context, messageID uint64, reason string) (*TxResult, error) {
+	return c.Send(ctx, model.TxTypeFlag, model.FlagTx{MessageID: messageID, Reason: reason})
+}
+
+// Ban bans a user; the account must be a moderator
+func (c *Client) Ban(ctx context.Context, user string, reason string) (*TxResult, error) {
+	return c.Send(ctx, model.TxTypeBanUser, model.BanUserTx{User: user, Reason: reason})
+}
+
+// Appeal asks the moderators to lift the ban of the account
+func (c *Client) Appeal(ctx context.Context, reason string) (*TxResult, error) {
+	return c.Send(ctx, model.TxTypeAppeal, model.AppealTx{Reason: reason})
+}
+
+// ResolveAppeal accepts or rejects the appeal of a user; the account must be a moderator
+func (c *Client) ResolveAppeal(ctx context.Context, user string, accept bool) (*TxResult, error) {
+	return c.Send(ctx, model.TxTypeResolveAppeal, model.ResolveAppealTx{User: user, Accept: accept})
+}
+
+// Transfer sends tokens to another user
+func (c *Client) Transfer(ctx context.Context, to string, amount uint64) (*TxResult, error) {
+	return c.Send(ctx, model.TxTypeTransfer, model.TransferTx{To: to, Amount: amount})
+}
+
+// SetProfile replaces the profile of the account
+func (c *Client) SetProfile(ctx context.Context, profile model.Profile) (*TxResult, error) {
+	return c.Send(ctx, model.TxTypeProfile, profile)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/alijnmerchant21/forum-updated/keyring"
+)
+
+func keysCommand(home string, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("expected generate, import, export or list")
+	}
+	kr, err := keyring.New(home)
+	if err != nil {
+		return err
+	}
+	switch args[0] {
+	case "generate":
+		args, err := parseArgs(newFlagSet("keys generate", "<name>"), args[1:], 1)
+		if err != nil {
+			return err
+		}
+		key, err := kr.Generate(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\t%s\n", key.Name, base64.StdEncoding.EncodeToString(key.PubKey()))
+		return nil
+	case "import":
+		args, err := parseArgs(newFlagSet("keys import", "<name> <file written by keys export>"), args[1:], 2)
+		if err != nil {
+			return err
+		}
+		keyBytes, err := os.ReadFile(args[1])
+		if err != nil {
+			return err
+		}
+		var exported keyring.Key
+		if err := json.Unmarshal(keyBytes, &exported); err != nil {
+			return fmt.Errorf("invalid key file: %w", err)
+		}
+		key, err := kr.Import(args[0], exported.PrivKey)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\t%s\n", key.Name, base64.StdEncoding.EncodeToString(key.PubKey()))
+		return nil
+	case "export":
+		args, err := parseArgs(newFlagSet("keys export", "<name>"), args[1:], 1)
+		if err != nil {
+			return err
+		}
+		key, err := kr.Get(args[0])
+		if err != nil {
+			return err
+		}
+		keyBytes, err := json.MarshalIndent(key, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, "This is the private key of the account, keep it secret")
+		fmt.Println(string(keyBytes))
+		return nil
+	case "list":
+		if _, err := parseArgs(newFlagSet("keys list", ""), args[1:], 0); err != nil {
+			return err
+		}
+		keys, err := kr.List()
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			fmt.Printf("%s\t%s\n", key.Name, base64.StdEncoding.EncodeToString(key.PubKey()))
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown keys command %q, expected generate, import, export or list", args[0])
+	}
+}
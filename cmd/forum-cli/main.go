@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// command is a subcommand of forum-cli
+type command struct {
+	name  string
+	usage string
+	run   func(home string, args []string) error
+}
+
+var commands = []command{
+	{"keys", "Manage the keys of the accounts: generate, import, export, list", keysCommand},
+	{"register", "Register an account with its key", registerCommand},
+	{"post", "Post a message", postCommand},
+	{"reply", "Reply to a message", replyCommand},
+	{"edit", "Change the text of a message", editCommand},
+	{"delete", "Delete a message", deleteCommand},
+	{"flag", "Report a message as inappropriate", flagCommand},
+	{"ban", "Ban a user (moderators)", banCommand},
+	{"appeal", "Ask the moderators to lift a ban", appealCommand},
+	{"resolve-appeal", "Accept or reject the appeal of a banned user (moderators)", resolveAppealCommand},
+	{"query", "Read the state: user, messages, history, bans, moderators, params, proposals, flags", queryCommand},
+}
+
+func main() {
+	flag.Usage = usage
+	home := flag.String("home", os.ExpandEnv("$HOME/.forum-cli"), "Directory of the keyring")
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	name := flag.Arg(0)
+	for _, cmd := range commands {
+		if cmd.name == name {
+			if err := cmd.run(*home, flag.Args()[1:]); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: forum-cli [-home DIR] <command> [flags] [args]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-15s %s\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprintf(out, "\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+// newFlagSet returns the flags of a subcommand taking the given arguments
+func newFlagSet(name string, args string) *flag.FlagSet {
+	flags := flag.NewFlagSet("forum-cli "+name, flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: forum-cli %s [flags] %s\n", name, args)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parseArgs parses the flags of a subcommand and checks the number of its arguments
+func parseArgs(flags *flag.FlagSet, args []string, n int) ([]string, error) {
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() != n {
+		flags.Usage()
+		return nil, fmt.Errorf("expected %d arguments, got %d", n, flags.NArg())
+	}
+	return flags.Args(), nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Output formats of the queries
+const (
+	// outputJSON prints the result as returned by the node
+	outputJSON = "json"
+	// outputTable prints aligned columns under a header
+	outputTable = "table"
+	// outputText prints tab separated columns without a header, for scripts
+	outputText = "text"
+)
+
+// table is a result as rows of columns
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+// printResult prints the JSON value of a query in the output format, using
+// toTable for the table and text formats
+func printResult(w io.Writer, format string, value []byte, toTable func([]byte) (*table, error)) error {
+	switch format {
+	case outputJSON:
+		var out bytes.Buffer
+		if err := json.Indent(&out, value, "", "  "); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintln(w, out.String())
+		return err
+	case outputTable, outputText:
+		t, err := toTable(value)
+		if err != nil {
+			return err
+		}
+		if format == outputText {
+			for _, row := range t.rows {
+				if _, err := fmt.Fprintln(w, strings.Join(row, "\t")); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(t.header, "\t"))
+		for _, row := range t.rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown output format %q, expected %q, %q or %q", format, outputJSON, outputTable, outputText)
+	}
+}
+
+func printStdout(format string, value []byte, toTable func([]byte) (*table, error)) error {
+	return printResult(os.Stdout, format, value, toTable)
+}
+
+// fieldsTable shows a JSON object as one row per field, nested fields
+// named with dots
+func fieldsTable(value []byte) (*table, error) {
+	var obj interface{}
+	if err := json.Unmarshal(value, &obj); err != nil {
+		return nil, err
+	}
+	t := &table{header: []string{"FIELD", "VALUE"}}
+	flatten("", obj, t)
+	return t, nil
+}
+
+func flatten(prefix string, v interface{}, t *table) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			name := k
+			if prefix != "" {
+				name = prefix + "." + k
+			}
+			flatten(name, v[k], t)
+		}
+	default:
+		t.rows = append(t.rows, []string{prefix, formatValue(v)})
+	}
+}
+
+// formatValue shows a JSON value in a cell
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64, bool:
+		return fmt.Sprint(v)
+	default:
+		bz, _ := json.Marshal(v)
+		return string(bz)
+	}
+}
+
+// namesTable shows a JSON list of names
+func namesTable(header string) func([]byte) (*table, error) {
+	return func(value []byte) (*table, error) {
+		var names []string
+		if err := json.Unmarshal(value, &names); err != nil {
+			return nil, err
+		}
+		t := &table{header: []string{header}}
+		for _, name := range names {
+			t.rows = append(t.rows, []string{name})
+		}
+		return t, nil
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	rpcclient "github.com/cometbft/cometbft/rpc/client"
+	rpchttp "github.com/cometbft/cometbft/rpc/client/http"
+
+	forum "github.com/alijnmerchant21/forum-updated/abci"
+	"github.com/alijnmerchant21/forum-updated/model"
+)
+
+// queryOptions are the flags of the query commands
+type queryOptions struct {
+	node   string
+	height int64
+	output string
+}
+
+// querySpec is a query command: the query path it sends, its arguments and
+// how its result shows as a table
+type querySpec struct {
+	path    string
+	args    string
+	nargs   int
+	data    func(args []string) (string, error)
+	toTable func([]byte) (*table, error)
+}
+
+var querySpecs = map[string]querySpec{
+	"user": {
+		path: forum.QueryPathUser, args: "<name>", nargs: 1,
+		toTable: fieldsTable,
+	},
+	"messages": {
+		path: forum.QueryPathMessages, args: "<sender>", nargs: 1,
+		toTable: messagesTable,
+	},
+	"history": {
+		path: forum.QueryPathHistory, args: "[page]", nargs: -1,
+		toTable: historyTable,
+	},
+	"bans": {
+		path:    forum.QueryPathBans,
+		toTable: namesTable("USER"),
+	},
+	"moderators": {
+		path:    forum.QueryPathModerators,
+		toTable: namesTable("USER"),
+	},
+	"params": {
+		path:    forum.QueryPathParams,
+		toTable: fieldsTable,
+	},
+	"proposals": {
+		path:    forum.QueryPathProposals,
+		toTable: proposalsTable,
+	},
+	"flags": {
+		path: forum.QueryPathFlags, args: "<message ID>", nargs: 1,
+		toTable: flagsTable,
+	},
+}
+
+func queryCommand(_ string, args []string) error {
+	if len(args) == 0 {
+		return errors.New("expected user, messages, history, bans, moderators, params, proposals or flags")
+	}
+	spec, ok := querySpecs[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown query %q", args[0])
+	}
+	flags := newFlagSet("query "+args[0], spec.args)
+	opts := new(queryOptions)
+	flags.StringVar(&opts.node, "node", "http://127.0.0.1:26657", "RPC endpoint of the node")
+	flags.Int64Var(&opts.height, "height", 0, "Height to read the state at (0 for the latest height)")
+	flags.StringVar(&opts.output, "output", outputTable, "Output format: \"json\", \"table\" or \"text\"")
+	var limit *int
+	if spec.path == forum.QueryPathHistory {
+		limit = flags.Int("limit", 20, "Number of entries per page")
+	}
+
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	queryArgs := flags.Args()
+	// A negative count is one optional argument
+	if (spec.nargs >= 0 && len(queryArgs) != spec.nargs) || (spec.nargs < 0 && len(queryArgs) > 1) {
+		flags.Usage()
+		return fmt.Errorf("unexpected arguments %q", queryArgs)
+	}
+
+	var data string
+	var err error
+	switch {
+	case spec.path == forum.QueryPathHistory:
+		page := 1
+		if len(queryArgs) > 0 {
+			if page, err = strconv.Atoi(queryArgs[0]); err != nil || page < 1 {
+				return fmt.Errorf("invalid page %q", queryArgs[0])
+			}
+		}
+		data = fmt.Sprintf("%d/%d", page, *limit)
+	case len(queryArgs) > 0:
+		data = queryArgs[0]
+	}
+
+	value, err := query(context.Background(), opts, spec.path, []byte(data))
+	if err != nil {
+		return err
+	}
+	return printStdout(opts.output, value, spec.toTable)
+}
+
+// query sends an abci_query and returns its value
+func query(ctx context.Context, opts *queryOptions, path string, data []byte) ([]byte, error) {
+	rpc, err := rpchttp.New(opts.node, "/websocket")
+	if err != nil {
+		return nil, err
+	}
+	res, err := rpc.ABCIQueryWithOptions(ctx, path, data, rpcclient.ABCIQueryOptions{Height: opts.height})
+	if err != nil {
+		return nil, err
+	}
+	resp := res.Response
+	if !resp.IsOK() {
+		return nil, fmt.Errorf("query failed with code %d: %s", resp.Code, resp.Log)
+	}
+	return resp.Value, nil
+}
+
+func messagesTable(value []byte) (*table, error) {
+	var messages []model.Message
+	if err := json.Unmarshal(value, &messages); err != nil {
+		return nil, err
+	}
+	t := &table{header: []string{"ID", "BOARD", "REPLY_TO", "EDITED", "MESSAGE"}}
+	for _, m := range messages {
+		replyTo := ""
+		if m.ReplyTo != 0 {
+			replyTo = strconv.FormatUint(m.ReplyTo, 10)
+		}
+		t.rows = append(t.rows, []string{strconv.FormatUint(m.ID, 10), m.Board, replyTo, strconv.FormatBool(m.Edited), m.Message})
+	}
+	return t, nil
+}
+
+func historyTable(value []byte) (*table, error) {
+	var page model.HistoryPage
+	if err := json.Unmarshal(value, &page); err != nil {
+		return nil, err
+	}
+	t := &table{header: []string{"#", "SENDER", "MESSAGE"}}
+	for i, e := range page.Entries {
+		n := (page.Page-1)*page.Limit + i + 1
+		t.rows = append(t.rows, []string{strconv.Itoa(n), e.Sender, e.Message})
+	}
+	return t, nil
+}
+
+func proposalsTable(value []byte) (*table, error) {
+	var proposals []model.UpgradeProposal
+	if err := json.Unmarshal(value, &proposals); err != nil {
+		return nil, err
+	}
+	t := &table{header: []string{"ID", "NAME", "VERSION", "HEIGHT", "STATUS", "PROPOSER", "APPROVALS"}}
+	for _, p := range proposals {
+		t.rows = append(t.rows, []string{
+			strconv.FormatUint(p.ID, 10),
+			p.Plan.Name,
+			strconv.FormatUint(p.Plan.Version, 10),
+			strconv.FormatInt(p.Plan.Height, 10),
+			p.Status,
+			p.Proposer,
+			strconv.Itoa(len(p.Approvals)),
+		})
+	}
+	return t, nil
+}
+
+func flagsTable(value []byte) (*table, error) {
+	var flags []model.Flag
+	if err := json.Unmarshal(value, &flags); err != nil {
+		return nil, err
+	}
+	t := &table{header: []string{"USER", "HEIGHT", "REASON"}}
+	for _, f := range flags {
+		t.rows = append(t.rows, []string{f.User, strconv.FormatInt(f.Height, 10), f.Reason})
+	}
+	return t, nil
+}
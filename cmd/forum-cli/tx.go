@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/cometbft/cometbft/libs/bytes"
+
+	"github.com/alijnmerchant21/forum-updated/client"
+	"github.com/alijnmerchant21/forum-updated/keyring"
+	"github.com/alijnmerchant21/forum-updated/model"
+)
+
+// txOptions are the flags of the commands sending a transaction
+type txOptions struct {
+	from  string
+	node  string
+	mode  string
+	nonce int64
+}
+
+func newTxFlagSet(name string, args string) (*flag.FlagSet, *txOptions) {
+	flags := newFlagSet(name, args)
+	opts := new(txOptions)
+	flags.StringVar(&opts.from, "from", "", "Account sending the transaction, signed with its key in the keyring")
+	flags.StringVar(&opts.node, "node", "http://127.0.0.1:26657", "RPC endpoint of the node")
+	flags.StringVar(&opts.mode, "broadcast-mode", string(client.BroadcastSync), "When to return: \"async\" once sent, \"sync\" after CheckTx, \"commit\" once in a block")
+	flags.Int64Var(&opts.nonce, "nonce", -1, "Nonce of the transaction (if negative, the next nonce of the account)")
+	return flags, opts
+}
+
+// send signs a transaction of the account in opts.from and broadcasts it
+func send(home string, opts *txOptions, txType string, data interface{}) error {
+	if opts.from == "" {
+		return errors.New("the -from flag is required")
+	}
+	mode := client.BroadcastMode(opts.mode)
+	if mode != client.BroadcastAsync && mode != client.BroadcastSync && mode != client.BroadcastCommit {
+		return fmt.Errorf("unknown broadcast mode %q, expected %q, %q or %q", opts.mode, client.BroadcastAsync, client.BroadcastSync, client.BroadcastCommit)
+	}
+	kr, err := keyring.New(home)
+	if err != nil {
+		return err
+	}
+	key, err := kr.Get(opts.from)
+	if err != nil {
+		return err
+	}
+	c, err := client.NewHTTP(opts.node, key)
+	if err != nil {
+		return err
+	}
+	c.SetBroadcastMode(mode)
+	if opts.nonce >= 0 {
+		c.SetNonce(uint64(opts.nonce))
+	}
+
+	result, err := c.Send(context.Background(), txType, data)
+	if err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(txOutput{Hash: result.Hash, Height: result.Height, Events: result.Events}, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+// txOutput is the result of a transaction as printed
+type txOutput struct {
+	Hash   bytes.HexBytes `json:"hash"`
+	Height int64          `json:"height,omitempty"`
+	Events []abci.Event   `json:"events,omitempty"`
+}
+
+func parseMessageID(arg string) (uint64, error) {
+	id, err := strconv.ParseUint(arg, 10, 64)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid message ID %q", arg)
+	}
+	return id, nil
+}
+
+func registerCommand(home string, args []string) error {
+	flags, opts := newTxFlagSet("register", "")
+	recovery := flags.String("recovery-key", "", "Account in the keyring whose key can recover this one")
+	if _, err := parseArgs(flags, args, 0); err != nil {
+		return err
+	}
+	kr, err := keyring.New(home)
+	if err != nil {
+		return err
+	}
+	key, err := kr.Get(opts.from)
+	if err != nil {
+		return err
+	}
+	register := model.RegisterTx{PubKey: key.PubKey()}
+	if *recovery != "" {
+		recoveryKey, err := kr.Get(*recovery)
+		if err != nil {
+			return err
+		}
+		register.RecoveryKey = recoveryKey.PubKey()
+	}
+	return send(home, opts, model.TxTypeRegister, register)
+}
+
+func postCommand(home string, args []string) error {
+	flags, opts := newTxFlagSet("post", "<message>")
+	board := flags.String("board", "", "Board to post to (if empty, the default board)")
+	args, err := parseArgs(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	return send(home, opts, model.TxTypePost, model.PostTx{Message: args[0], Board: *board})
+}
+
+func replyCommand(home string, args []string) error {
+	flags, opts := newTxFlagSet("reply", "<message ID> <message>")
+	board := flags.String("board", "", "Board to post to (if empty, the default board)")
+	args, err := parseArgs(flags, args, 2)
+	if err != nil {
+		return err
+	}
+	id, err := parseMessageID(args[0])
+	if err != nil {
+		return err
+	}
+	return send(home, opts, model.TxTypePost, model.PostTx{Message: args[1], Board: *board, ReplyTo: id})
+}
+
+func editCommand(home string, args []string) error {
+	flags, opts := newTxFlagSet("edit", "<message ID> <message>")
+	args, err := parseArgs(flags, args, 2)
+	if err != nil {
+		return err
+	}
+	id, err := parseMessageID(args[0])
+	if err != nil {
+		return err
+	}
+	return send(home, opts, model.TxTypeEdit, model.EditTx{MessageID: id, Message: args[1]})
+}
+
+func deleteCommand(home string, args []string) error {
+	flags, opts := newTxFlagSet("delete", "<message ID>")
+	args, err := parseArgs(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	id, err := parseMessageID(args[0])
+	if err != nil {
+		return err
+	}
+	return send(home, opts, model.TxTypeDelete, model.DeleteTx{MessageID: id})
+}
+
+func flagCommand(home string, args []string) error {
+	flags, opts := newTxFlagSet("flag", "<message ID>")
+	reason := flags.String("reason", "", "Why the message is inappropriate")
+	args, err := parseArgs(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	id, err := parseMessageID(args[0])
+	if err != nil {
+		return err
+	}
+	return send(home, opts, model.TxTypeFlag, model.FlagTx{MessageID: id, Reason: *reason})
+}
+
+func banCommand(home string, args []string) error {
+	flags, opts := newTxFlagSet("ban", "<user>")
+	reason := flags.String("reason", "", "Why the user is banned")
+	args, err := parseArgs(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	return send(home, opts, model.TxTypeBanUser, model.BanUserTx{User: args[0], Reason: *reason})
+}
+
+func appealCommand(home string, args []string) error {
+	flags, opts := newTxFlagSet("appeal", "<reason>")
+	args, err := parseArgs(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	return send(home, opts, model.TxTypeAppeal, model.AppealTx{Reason: args[0]})
+}
+
+func resolveAppealCommand(home string, args []string) error {
+	flags, opts := newTxFlagSet("resolve-appeal", "<user>")
+	accept := flags.Bool("accept", false, "Lift the ban of the user (if false, the appeal is rejected)")
+	args, err := parseArgs(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	return send(home, opts, model.TxTypeResolveAppeal, model.ResolveAppealTx{User: args[0], Accept: *accept})
+}
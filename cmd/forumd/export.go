@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	forum "github.com/alijnmerchant21/forum-updated/abci"
+	"github.com/alijnmerchant21/forum-updated/model"
+	"github.com/alijnmerchant21/forum-updated/node"
+)
+
+// openDB opens the application state of a home directory. Like the node on
+// startup, it first finishes writing a block left half written by a crash.
+func openDB(home string) (*model.DB, error) {
+	appConfig, err := node.LoadAppConfig(home)
+	if err != nil {
+		return nil, err
+	}
+	forumDB, err := model.OpenDB(appConfig.DBBackend, appConfig.DBPath())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := forumDB.RecoverJournal(); err != nil {
+		forumDB.Close()
+		return nil, err
+	}
+	return forumDB, nil
+}
+
+func migrationsCommand(home string, args []string) error {
+	if err := newFlagSet("migrations").Parse(args); err != nil {
+		return err
+	}
+	forumDB, err := openDB(home)
+	if err != nil {
+		return err
+	}
+	defer forumDB.Close()
+	applied, changes, err := forumDB.DryRunMigrations()
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		fmt.Println("The database is at the latest schema version")
+		return nil
+	}
+	for _, m := range applied {
+		fmt.Printf("Schema version %d: %s\n", m.Version, m.Description)
+	}
+	for _, ch := range changes {
+		if ch.Delete {
+			fmt.Printf("delete %q\n", ch.Key)
+		} else {
+			fmt.Printf("set %q = %q\n", ch.Key, ch.Value)
+		}
+	}
+	return nil
+}
+
+// exportCommand writes the state at a height as the app_state of a genesis
+// file, to start a new chain with the same content:
+//
+//	forumd export [-height N] [-output app_state.json]
+func exportCommand(home string, args []string) error {
+	flags := newFlagSet("export")
+	height := flags.Int64("height", 0, "Height to export the state at (0 for the latest height)")
+	output := flags.String("output", "", "File to write the app_state to (if empty, writes to stdout)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	forumDB, err := openDB(home)
+	if err != nil {
+		return err
+	}
+	defer forumDB.Close()
+
+	var store model.KVStore = forumDB
+	if *height != 0 {
+		if store, err = forumDB.AtHeight(*height); err != nil {
+			return err
+		}
+	}
+	genesis, err := forum.ExportGenesis(store)
+	if err != nil {
+		return err
+	}
+	appState, err := json.MarshalIndent(genesis, "", "  ")
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err = fmt.Println(string(appState))
+		return err
+	}
+	return os.WriteFile(*output, appState, 0o644)
+}
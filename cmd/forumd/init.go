@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+
+	cfg "github.com/cometbft/cometbft/config"
+	cmtjson "github.com/cometbft/cometbft/libs/json"
+	cmtos "github.com/cometbft/cometbft/libs/os"
+	"github.com/cometbft/cometbft/p2p"
+	"github.com/cometbft/cometbft/privval"
+	cmtversion "github.com/cometbft/cometbft/version"
+
+	forum "github.com/alijnmerchant21/forum-updated/abci"
+	"github.com/alijnmerchant21/forum-updated/node"
+)
+
+// Version is the version of forumd, set at build time with
+// -ldflags "-X main.Version=..."
+var Version = "dev"
+
+func initCommand(home string, args []string) error {
+	flags := newFlagSet("init")
+	chainID := flags.String("chain-id", forum.DefaultConfig().ChainID, "ID of the chain")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := node.InitFiles(home, *chainID); err != nil {
+		return err
+	}
+	fmt.Printf("Initialized the node of chain %s in %s\n", *chainID, home)
+	return nil
+}
+
+func testnetCommand(_ string, args []string) error {
+	flags := newFlagSet("testnet")
+	validators := flags.Int("v", 4, "Number of validators")
+	output := flags.String("o", "./testnet", "Directory to create the home directories of the nodes in")
+	chainID := flags.String("chain-id", forum.DefaultConfig().ChainID, "ID of the chain")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := node.InitTestnet(*output, *validators, *chainID); err != nil {
+		return err
+	}
+	fmt.Printf("Initialized a testnet of %d validators in %s\n", *validators, *output)
+	return nil
+}
+
+func showNodeIDCommand(home string, args []string) error {
+	if err := newFlagSet("show-node-id").Parse(args); err != nil {
+		return err
+	}
+	config := cfg.DefaultConfig()
+	config.SetRoot(home)
+	nodeKey, err := p2p.LoadNodeKey(config.NodeKeyFile())
+	if err != nil {
+		return err
+	}
+	fmt.Println(nodeKey.ID())
+	return nil
+}
+
+func showValidatorCommand(home string, args []string) error {
+	if err := newFlagSet("show-validator").Parse(args); err != nil {
+		return err
+	}
+	config := cfg.DefaultConfig()
+	config.SetRoot(home)
+	keyFile := config.PrivValidatorKeyFile()
+	if !cmtos.FileExists(keyFile) {
+		return fmt.Errorf("no validator key in %s, run forumd init first", keyFile)
+	}
+	pv := privval.LoadFilePVEmptyState(keyFile, config.PrivValidatorStateFile())
+	pubKey, err := pv.GetPubKey()
+	if err != nil {
+		return err
+	}
+	bz, err := cmtjson.Marshal(pubKey)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(bz))
+	return nil
+}
+
+func versionCommand(_ string, args []string) error {
+	if err := newFlagSet("version").Parse(args); err != nil {
+		return err
+	}
+	fmt.Printf("forumd:      %s\n", Version)
+	fmt.Printf("application: %d\n", forum.ApplicationVersion)
+	fmt.Printf("cometbft:    %s\n", cmtversion.TMCoreSemVer)
+	fmt.Printf("abci:        %s\n", cmtversion.ABCISemVer)
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/alijnmerchant21/forum-updated/node"
+)
+
+// command is a subcommand of forumd
+type command struct {
+	name  string
+	usage string
+	run   func(home string, args []string) error
+}
+
+var commands = []command{
+	{"init", "Create the configuration, keys and genesis of a validator node", initCommand},
+	{"start", "Run the node", startCommand},
+	{"testnet", "Create the configuration of a local testnet of several validators", testnetCommand},
+	{"show-node-id", "Print the ID of the node", showNodeIDCommand},
+	{"show-validator", "Print the public key of the validator", showValidatorCommand},
+	{"export", "Write the state as the app_state of a genesis file", exportCommand},
+	{"migrations", "Print the pending data migrations and the changes they would make", migrationsCommand},
+	{"version", "Print the versions of forumd, the application and CometBFT", versionCommand},
+}
+
+func main() {
+	flag.Usage = usage
+	home := flag.String("home", node.DefaultHome, "Home directory of the node")
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	name := flag.Arg(0)
+	for _, cmd := range commands {
+		if cmd.name == name {
+			if err := cmd.run(*home, flag.Args()[1:]); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: forumd [-home DIR] <command> [flags]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-15s %s\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprintf(out, "\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+// newFlagSet returns the flags of a subcommand
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet("forumd "+name, flag.ExitOnError)
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	cfg "github.com/cometbft/cometbft/config"
+	cmtflags "github.com/cometbft/cometbft/libs/cli/flags"
+	cmtlog "github.com/cometbft/cometbft/libs/log"
+
+	forum "github.com/alijnmerchant21/forum-updated/abci"
+	"github.com/alijnmerchant21/forum-updated/client"
+	"github.com/alijnmerchant21/forum-updated/node"
+)
+
+// Modes of running the application
+const (
+	// modeNode runs CometBFT and the application in the same process
+	modeNode = "node"
+	// modeSocket and modeGRPC serve the application to a separate CometBFT process
+	modeSocket = forum.TransportSocket
+	modeGRPC   = forum.TransportGRPC
+)
+
+func startCommand(home string, args []string) error {
+	flags := newFlagSet("start")
+	mode := flags.String("mode", modeNode, "How to run: \"node\" runs CometBFT in process, \"socket\" and \"grpc\" serve the application to a separate CometBFT over ABCI")
+	abciAddr := flags.String("address", "tcp://127.0.0.1:26658", "Address the ABCI server listens on in socket and grpc modes")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	switch *mode {
+	case modeNode:
+		return runNode(home)
+	case modeSocket, modeGRPC:
+		return serveABCI(home, *mode, *abciAddr)
+	default:
+		return fmt.Errorf("unknown mode %q, expected %q, %q or %q", *mode, modeNode, modeSocket, modeGRPC)
+	}
+}
+
+// runNode runs CometBFT and the application in this process until it is interrupted
+func runNode(home string) error {
+	config, err := node.LoadConfig(home)
+	if err != nil {
+		return err
+	}
+	appConfig, err := node.LoadAppConfig(home)
+	if err != nil {
+		return err
+	}
+	if err := node.ValidateConfig(config, appConfig); err != nil {
+		return err
+	}
+	appLogger, err := forum.NewLogger(appConfig, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	app, err := forum.NewForumApp(appConfig, appLogger)
+	if err != nil {
+		return fmt.Errorf("failed to create ForumApp instance: %w", err)
+	}
+	defer app.Close()
+
+	logger := cmtlog.NewTMLogger(cmtlog.NewSyncWriter(os.Stdout))
+	logger, err = cmtflags.ParseLogLevel(config.LogLevel, logger, cfg.DefaultLogLevel)
+	if err != nil {
+		return fmt.Errorf("failed to parse log level: %w", err)
+	}
+
+	n, err := node.New(config, app, logger)
+	if err != nil {
+		return err
+	}
+
+	if err := n.Start(); err != nil {
+		return fmt.Errorf("failed to start CometBFT node: %w", err)
+	}
+	defer func() {
+		n.Stop()
+		n.Wait()
+	}()
+
+	if appConfig.HTTPAddress != "" {
+		server := serveHTTP(appConfig.HTTPAddress, client.NewLocal(n, nil), appLogger.With("module", "http"))
+		defer server.Close()
+	}
+
+	if err := waitForShutdown(home, app, appLogger); err != nil {
+		return err
+	}
+	fmt.Println("Forum application stopped")
+	return nil
+}
+
+// serveHTTP serves the HTTP API of the node in the background: GET
+// /messages?sender=<name> returns the messages posted by the user as JSON.
+// It queries through the node, so it doesn't race with the blocks being
+// executed.
+func serveHTTP(addr string, c *client.Client, logger cmtlog.Logger) *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/messages", func(w http.ResponseWriter, r *http.Request) {
+		sender := r.URL.Query().Get("sender")
+		if sender == "" {
+			http.Error(w, "missing sender parameter", http.StatusBadRequest)
+			return
+		}
+		messages, err := c.GetMessages(r.Context(), sender)
+		var queryErr *client.QueryError
+		switch {
+		case errors.As(err, &queryErr) && queryErr.Code == forum.CodeTypeNotFound:
+			http.Error(w, queryErr.Log, http.StatusNotFound)
+			return
+		case errors.As(err, &queryErr):
+			http.Error(w, queryErr.Log, http.StatusBadRequest)
+			return
+		case err != nil:
+			logger.Error("failed to query the messages", "sender", sender, "err", err)
+			http.Error(w, "failed to query the messages", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(messages); err != nil {
+			logger.Error("failed to write the response", "err", err)
+		}
+	})
+
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("HTTP API stopped", "addr", addr, "err", err)
+		}
+	}()
+	logger.Info("serving the HTTP API", "addr", addr)
+	return server
+}
+
+// serveABCI serves the application over the ABCI socket or gRPC server until
+// the process is interrupted. CometBFT runs in its own process and connects
+// to it, so either can be restarted without the other.
+func serveABCI(home string, mode string, abciAddr string) error {
+	appConfig, err := node.LoadAppConfig(home)
+	if err != nil {
+		return err
+	}
+	logger, err := forum.NewLogger(appConfig, os.Stdout)
+	if err != nil {
+		return err
+	}
+	app, err := forum.NewForumApp(appConfig, logger)
+	if err != nil {
+		return err
+	}
+	defer app.Close()
+
+	server, err := forum.NewServer(app, mode, abciAddr)
+	if err != nil {
+		return err
+	}
+	server.SetLogger(logger.With("module", "abci-server"))
+	if err := server.Start(); err != nil {
+		return err
+	}
+	fmt.Printf("Serving the forum over ABCI %s at %s\n", mode, abciAddr)
+
+	if err := waitForShutdown(home, app, logger); err != nil {
+		return err
+	}
+	return server.Stop()
+}
+
+// waitForShutdown returns when the process is interrupted. Until then, the
+// curse words of app.toml are reloaded when the file changes or on SIGHUP.
+func waitForShutdown(home string, app *forum.ForumApp, logger cmtlog.Logger) error {
+	file := node.AppConfigFile(home)
+	logger = logger.With("module", "forum")
+	reloadFailed := func(err error) {
+		logger.Error("failed to reload the config, keeping the current curse words", "file", file, "err", err)
+	}
+	stopWatching, err := forum.WatchConfig(app, file, reloadFailed)
+	if err != nil {
+		return fmt.Errorf("failed to watch %s: %w", file, err)
+	}
+	defer stopWatching()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(sigCh)
+	for sig := range sigCh {
+		if sig != syscall.SIGHUP {
+			return nil
+		}
+		if err := app.ReloadConfig(file); err != nil {
+			reloadFailed(err)
+		}
+	}
+	return nil
+}
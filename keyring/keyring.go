@@ -0,0 +1,129 @@
+// Package keyring keeps the ed25519 keys of forum accounts on disk, one JSON
+// file per account in a directory only the owner can read.
+package keyring
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cometbft/cometbft/crypto/ed25519"
+
+	"github.com/alijnmerchant21/forum-updated/model"
+)
+
+// ErrKeyNotFound is returned for accounts without a key in the keyring
+var ErrKeyNotFound = errors.New("key not found")
+
+const keyFileSuffix = ".json"
+
+// Key is the private key of an account. Its name is the name of the account.
+type Key struct {
+	Name    string          `json:"name"`
+	PrivKey ed25519.PrivKey `json:"priv_key"`
+}
+
+// PubKey returns the public key registered for the account
+func (k *Key) PubKey() ed25519.PubKey {
+	return k.PrivKey.PubKey().(ed25519.PubKey)
+}
+
+// Keyring stores keys in a directory
+type Keyring struct {
+	dir string
+}
+
+// New opens the keyring in dir, creating the directory if needed
+func New(dir string) (*Keyring, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &Keyring{dir: dir}, nil
+}
+
+func (kr *Keyring) file(name string) string {
+	return filepath.Join(kr.dir, name+keyFileSuffix)
+}
+
+// Generate creates a new key for the account
+func (kr *Keyring) Generate(name string) (*Key, error) {
+	return kr.Import(name, ed25519.GenPrivKey())
+}
+
+// Import stores an existing key for the account. Keys are never replaced, so
+// importing a name that already has a key fails.
+func (kr *Keyring) Import(name string, privKey ed25519.PrivKey) (*Key, error) {
+	if err := model.ValidateUserName(name); err != nil {
+		return nil, err
+	}
+	if len(privKey) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("private key must be %d bytes", ed25519.PrivateKeySize)
+	}
+	key := &Key{Name: name, PrivKey: privKey}
+	keyBytes, err := json.MarshalIndent(key, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(kr.file(name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, os.ErrExist) {
+		return nil, fmt.Errorf("a key named %s already exists", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(keyBytes); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return key, f.Close()
+}
+
+// Get returns the key of the account
+func (kr *Keyring) Get(name string) (*Key, error) {
+	if err := model.ValidateUserName(name); err != nil {
+		return nil, err
+	}
+	keyBytes, err := os.ReadFile(kr.file(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var key Key
+	if err := json.Unmarshal(keyBytes, &key); err != nil {
+		return nil, fmt.Errorf("invalid key file for %s: %w", name, err)
+	}
+	if len(key.PrivKey) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid key file for %s: private key must be %d bytes", name, ed25519.PrivateKeySize)
+	}
+	return &key, nil
+}
+
+// List returns the keys of the keyring, by name
+func (kr *Keyring) List() ([]*Key, error) {
+	entries, err := os.ReadDir(kr.dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), keyFileSuffix) {
+			names = append(names, strings.TrimSuffix(e.Name(), keyFileSuffix))
+		}
+	}
+	sort.Strings(names)
+	keys := make([]*Key, 0, len(names))
+	for _, name := range names {
+		key, err := kr.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
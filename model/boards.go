@@ -0,0 +1,66 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// MaxBoardNameLength is the longest name a board can have
+const MaxBoardNameLength = 32
+
+var boardNameRegexp = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// Board is a place messages are posted to. The boards of the chain are set in
+// the genesis; when none are, messages can be posted to any board.
+type Board struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// ValidateBoardName checks that a name can be used for a board
+func ValidateBoardName(name string) error {
+	if len(name) == 0 || len(name) > MaxBoardNameLength {
+		return fmt.Errorf("board name must have between 1 and %d characters", MaxBoardNameLength)
+	}
+	if !boardNameRegexp.MatchString(name) {
+		return fmt.Errorf("board name %q may only contain lower case letters, digits, '_' and '-'", name)
+	}
+	return nil
+}
+
+// GetBoards returns the boards of the chain, nil if any board can be used
+func GetBoards(db KVStore) ([]Board, error) {
+	value, err := db.Get(boardsKey)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	var boards []Board
+	err = json.Unmarshal(value, &boards)
+	return boards, err
+}
+
+func SetBoards(db KVStore, boards []Board) error {
+	value, err := json.Marshal(boards)
+	if err != nil {
+		return err
+	}
+	return db.Set(boardsKey, value)
+}
+
+// HasBoard reports whether messages can be posted to the board
+func HasBoard(db KVStore, name string) (bool, error) {
+	boards, err := GetBoards(db)
+	if err != nil {
+		return false, err
+	}
+	if boards == nil {
+		return true, nil
+	}
+	for _, b := range boards {
+		if b.Name == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
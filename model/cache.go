@@ -0,0 +1,142 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// Change is a single staged write. A Change with Delete set removes the key.
+type Change struct {
+	Key    []byte `json:"key"`
+	Value  []byte `json:"value,omitempty"`
+	Delete bool   `json:"delete,omitempty"`
+}
+
+// Cache stages writes on top of a DB without touching it. Reads go through
+// the pending writes first, so transactions later in a block see the effects
+// of the ones before them. Nothing reaches the database until Write is called.
+type Cache struct {
+	db      *DB
+	pending map[string]Change
+}
+
+func (db *DB) NewCache() *Cache {
+	return &Cache{
+		db:      db,
+		pending: make(map[string]Change),
+	}
+}
+
+func (c *Cache) Get(key []byte) ([]byte, error) {
+	if ch, ok := c.pending[string(key)]; ok {
+		if ch.Delete {
+			return nil, nil
+		}
+		return ch.Value, nil
+	}
+	return c.db.Get(key)
+}
+
+func (c *Cache) Set(key, value []byte) error {
+	c.pending[string(key)] = Change{Key: key, Value: value}
+	return nil
+}
+
+func (c *Cache) Delete(key []byte) error {
+	c.pending[string(key)] = Change{Key: key, Delete: true}
+	return nil
+}
+
+// Iterate walks the committed keys with the given prefix merged with the
+// pending writes, in key order.
+func (c *Cache) Iterate(prefix []byte, fn func(key, value []byte) error) error {
+	merged := make(map[string][]byte)
+	err := c.db.Iterate(prefix, func(key, value []byte) error {
+		merged[string(key)] = value
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for k, ch := range c.pending {
+		if !strings.HasPrefix(k, string(prefix)) {
+			continue
+		}
+		if ch.Delete {
+			delete(merged, k)
+		} else {
+			merged[k] = ch.Value
+		}
+	}
+	keys := make([]string, 0, len(merged))
+	for k := range merged {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := fn([]byte(k), merged[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Changes returns the staged writes sorted by key.
+func (c *Cache) Changes() []Change {
+	changes := make([]Change, 0, len(c.pending))
+	for _, ch := range c.pending {
+		changes = append(changes, ch)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return bytes.Compare(changes[i].Key, changes[j].Key) < 0
+	})
+	return changes
+}
+
+// Write persists the staged writes and empties the cache. The change set is
+// journaled first, so a crash in the middle of the write can be completed by
+// RecoverJournal on the next start.
+func (c *Cache) Write() error {
+	changes := c.Changes()
+	journal, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	if err := c.db.Set(journalKey, journal); err != nil {
+		return err
+	}
+	if err := c.db.applyChanges(changes); err != nil {
+		return err
+	}
+	if err := c.db.Delete(journalKey); err != nil {
+		return err
+	}
+	c.pending = make(map[string]Change)
+	return nil
+}
+
+// RecoverJournal applies a change set left behind by an interrupted
+// Cache.Write. It reports whether anything had to be recovered.
+func (db *DB) RecoverJournal() (bool, error) {
+	key := journalKey
+	journal, err := db.Get(key)
+	if err == nil && journal == nil {
+		// left by a binary from before schema version 2
+		key = legacyJournalKey
+		journal, err = db.Get(key)
+	}
+	if err != nil || journal == nil {
+		return false, err
+	}
+	var changes []Change
+	if err := json.Unmarshal(journal, &changes); err != nil {
+		return false, err
+	}
+	if err := db.applyChanges(changes); err != nil {
+		return false, err
+	}
+	db.logger.Info("recovered a partially written block", "changes", len(changes))
+	return true, db.Delete(key)
+}
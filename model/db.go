@@ -3,162 +3,174 @@ package model
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
-	"strings"
 
 	"github.com/cometbft/cometbft/abci/types"
-	"github.com/dgraph-io/badger/v3"
+	cmtlog "github.com/cometbft/cometbft/libs/log"
 	"github.com/pkg/errors"
 )
 
+// DB is the committed state of the forum, kept in a Store
 type DB struct {
-	db *badger.DB
+	store  Store
+	logger cmtlog.Logger
 }
 
-func (db *DB) Init(database *badger.DB) {
-	db.db = database
+// KVStore is the key-value view the forum logic reads from and writes to.
+// It is implemented by DB, which works on the committed state, and by Cache,
+// which stages the writes of a block on top of it.
+// Get returns a nil value and no error when the key does not exist.
+type KVStore interface {
+	Get(key []byte) ([]byte, error)
+	Set(key, value []byte) error
+	Delete(key []byte) error
+	Iterate(prefix []byte, fn func(key, value []byte) error) error
 }
 
-func (db *DB) Commit() error {
-	return db.db.Update(func(txn *badger.Txn) error {
-		return txn.Commit()
-	})
+func (db *DB) Init(store Store) {
+	db.store = store
+	db.logger = cmtlog.NewNopLogger()
+}
+
+// SetLogger sets the logger of the database, which logs nothing by default
+func (db *DB) SetLogger(logger cmtlog.Logger) {
+	db.logger = logger
 }
 
+// NewDB opens a Badger database in dbPath
 func NewDB(dbPath string) (*DB, error) {
-	fmt.Println("New DB")
-	// Open badger DB
-	opts := badger.DefaultOptions(dbPath)
-	db, err := badger.Open(opts)
+	return OpenDB(BackendBadger, dbPath)
+}
+
+// OpenDB opens the database of the given backend in dir
+func OpenDB(backend string, dir string) (*DB, error) {
+	store, err := OpenStore(backend, dir)
 	if err != nil {
 		return nil, err
 	}
-
-	// Create a new DB instance and initialize with badger DB
 	dbInstance := &DB{}
-	dbInstance.Init(db)
-
+	dbInstance.Init(store)
 	return dbInstance, nil
-
 }
 
-func (db *DB) GetDB() *badger.DB {
-	return db.db
-}
-
-func (db *DB) Size() int64 {
-	lsm, vlog := db.GetDB().Size()
-	return lsm + vlog
+// Store returns the store the DB is kept in
+func (db *DB) Store() Store {
+	return db.store
 }
 
 func (db *DB) CreateUser(user *User) error {
 	// Check if the user already exists
-	err := db.db.View(func(txn *badger.Txn) error {
-		_, err := txn.Get([]byte(user.Name))
+	existing, err := db.Get(UserKey(user.Name))
+	if err != nil {
 		return err
-	})
-	if err == nil {
+	}
+	if existing != nil {
 		return errors.New("user already exists")
 	}
-
-	// Save the user to the database
-	err = db.db.Update(func(txn *badger.Txn) error {
-		userBytes, err := json.Marshal(user)
-		if err != nil {
-			return errors.Wrap(err, "failed to marshal user to JSON")
-		}
-		err = txn.Set([]byte(user.Name), userBytes)
-		if err != nil {
-			return err
-		}
-		return nil
-	})
-	return err
+	return SetUser(db, user)
 }
 
 func (db *DB) FindUserByName(name string) (*User, error) {
-	// Read the user from the database
-	var user *User
-	err := db.db.View(func(txn *badger.Txn) error {
-		item, err := txn.Get([]byte(name))
-		if err != nil {
-			return err
-		}
-		err = item.Value(func(val []byte) error {
-			return json.Unmarshal(val, &user)
-		})
-		return err
-	})
+	user, err := GetUser(db, name)
 	if err != nil {
-		fmt.Println("Error in retrieving user: ", err)
+		db.logger.Debug("failed to read user", "user", name, "err", err)
 		return nil, err
 	}
-
 	return user, nil
 }
 
-func (db *DB) Set(key, value []byte) error {
-	return db.db.Update(func(txn *badger.Txn) error {
-		return txn.Set(key, value)
-	})
+// GetUser reads a user from the given store. It returns ErrNotFound if there
+// is no such user.
+func GetUser(store KVStore, name string) (*User, error) {
+	userBytes, err := store.Get(UserKey(name))
+	if err != nil {
+		return nil, err
+	}
+	if userBytes == nil {
+		return nil, ErrNotFound
+	}
+	var user *User
+	if err := json.Unmarshal(userBytes, &user); err != nil {
+		return nil, err
+	}
+	return user, nil
 }
 
-func ViewDB(db *badger.DB, key []byte) ([]byte, error) {
-	var value []byte
-	err := db.View(func(txn *badger.Txn) error {
-		item, err := txn.Get(key)
-		if err != nil {
-			if err != badger.ErrKeyNotFound {
-				return err
-			}
-			return nil
-		}
-		value, err = item.ValueCopy(nil)
-		return err
-	})
+// SetUser writes a user to the given store, in the layout of the latest
+// schema version.
+func SetUser(store KVStore, user *User) error {
+	user.SchemaVersion = LatestSchemaVersion()
+	userBytes, err := json.Marshal(user)
 	if err != nil {
-		return nil, err
+		return errors.Wrap(err, "failed to marshal user to JSON")
 	}
-	return value, nil
+	return store.Set(UserKey(user.Name), userBytes)
 }
 
-func (db *DB) Close() error {
-	return db.db.Close()
+// ListUsers returns the names of the users matching the filter, in order
+func ListUsers(store KVStore, filter func(*User) bool) ([]string, error) {
+	var names []string
+	err := store.Iterate([]byte(PrefixUser), func(_, value []byte) error {
+		var u User
+		if err := json.Unmarshal(value, &u); err != nil {
+			return err
+		}
+		if filter(&u) {
+			names = append(names, u.Name)
+		}
+		return nil
+	})
+	return names, err
 }
 
 func (db *DB) Get(key []byte) ([]byte, error) {
+	return db.store.Get(key)
+}
 
-	return ViewDB(db.db, key)
+func (db *DB) Set(key, value []byte) error {
+	return db.store.Set(key, value)
+}
+
+func (db *DB) Delete(key []byte) error {
+	return db.store.Delete(key)
+}
+
+// Iterate calls fn for every key with the given prefix, in key order.
+func (db *DB) Iterate(prefix []byte, fn func(key, value []byte) error) error {
+	return db.store.Iterate(prefix, fn)
+}
+
+// applyChanges writes a change set in a single batch. Depending on the
+// backend the batch may not be atomic; see Cache.Write.
+func (db *DB) applyChanges(changes []Change) error {
+	batch := db.store.NewBatch()
+	defer batch.Discard()
+	for _, ch := range changes {
+		var err error
+		if ch.Delete {
+			err = batch.Delete(ch.Key)
+		} else {
+			err = batch.Set(ch.Key, ch.Value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return batch.Write()
+}
 
+func (db *DB) Close() error {
+	return db.store.Close()
 }
+
+// GetValidators returns the validators stored by InitChain and FinalizeBlock
 func (db *DB) GetValidators() (validators []types.ValidatorUpdate, err error) {
-	err = db.db.View(func(txn *badger.Txn) error {
-		opts := badger.DefaultIteratorOptions
-		opts.PrefetchSize = 10
-		it := txn.NewIterator(opts)
-		defer it.Close()
-		for it.Rewind(); it.Valid(); it.Next() {
-			var err error
-			item := it.Item()
-			k := item.Key()
-			if isValidatorTx(k) {
-				err := item.Value(func(v []byte) error {
-					validator := new(types.ValidatorUpdate)
-					err = types.ReadMessage(bytes.NewBuffer(v), validator)
-					if err == nil {
-						validators = append(validators, *validator)
-					}
-					return err
-				})
-				if err != nil {
-					return err
-				}
-			}
+	err = db.Iterate([]byte(PrefixValidator), func(_, value []byte) error {
+		validator := new(types.ValidatorUpdate)
+		if err := types.ReadMessage(bytes.NewBuffer(value), validator); err != nil {
+			return err
 		}
+		validators = append(validators, *validator)
 		return nil
 	})
 	return
 }
-func isValidatorTx(tx []byte) bool {
-	return strings.HasPrefix(string(tx), "val")
-}
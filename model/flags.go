@@ -0,0 +1,61 @@
+package model
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// MaxReasonLength is the maximum length of the reason of a flag or an appeal
+const MaxReasonLength = 512
+
+// Flag is a report by a user of a message it finds inappropriate
+type Flag struct {
+	User   string `json:"user"`
+	Reason string `json:"reason,omitempty"`
+	Height int64  `json:"height"`
+}
+
+// FlagsKey is the key of the flags of a message
+func FlagsKey(messageID uint64) []byte {
+	return prefixed(PrefixFlag, strconv.FormatUint(messageID, 10))
+}
+
+// GetFlags returns the flags of a message, oldest first
+func GetFlags(db KVStore, messageID uint64) ([]Flag, error) {
+	value, err := db.Get(FlagsKey(messageID))
+	if err != nil || value == nil {
+		return nil, err
+	}
+	var flags []Flag
+	if err := json.Unmarshal(value, &flags); err != nil {
+		return nil, err
+	}
+	return flags, nil
+}
+
+// HasFlagged reports whether the user already flagged the message
+func HasFlagged(db KVStore, messageID uint64, user string) (bool, error) {
+	flags, err := GetFlags(db, messageID)
+	if err != nil {
+		return false, err
+	}
+	for _, f := range flags {
+		if f.User == user {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// AddFlag records a flag of a message
+func AddFlag(db KVStore, messageID uint64, flag Flag) error {
+	flags, err := GetFlags(db, messageID)
+	if err != nil {
+		return err
+	}
+	value, err := json.Marshal(append(flags, flag))
+	if err != nil {
+		return err
+	}
+	return db.Set(FlagsKey(messageID), value)
+}
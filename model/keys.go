@@ -0,0 +1,89 @@
+package model
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// The keyspace is split by record type: every key starts with the prefix of
+// its type and a '/', followed by the name of the record. Keys of different
+// types can't collide, whatever names users choose.
+const (
+	PrefixUser      = "user/"
+	PrefixMessages  = "msg/"
+	PrefixValidator = "val/"
+	PrefixBalance   = "bal/"
+	PrefixRateLimit = "rate/"
+	PrefixProfile   = "profile/"
+	PrefixFlag      = "flag/"
+	// PrefixRank orders the users that posted for the leaderboard
+	PrefixRank = "rank/"
+	// PrefixMeta holds the records that exist once per chain
+	PrefixMeta = "meta/"
+	// PrefixVersion holds the values keys had before each block, see versions.go
+	PrefixVersion = "ver/"
+)
+
+func prefixed(prefix string, name string) []byte {
+	return []byte(prefix + name)
+}
+
+// UserKey is the key of the account of a user
+func UserKey(name string) []byte {
+	return prefixed(PrefixUser, name)
+}
+
+// MessagesKey is the key of the messages posted by a user
+func MessagesKey(sender string) []byte {
+	return prefixed(PrefixMessages, sender)
+}
+
+// ValidatorKey is the key of a validator, by public key
+func ValidatorKey(pubKey []byte) []byte {
+	return prefixed(PrefixValidator, string(pubKey))
+}
+
+// BalanceKey is the key of the token balance of a user
+func BalanceKey(user string) []byte {
+	return prefixed(PrefixBalance, user)
+}
+
+// RateLimitKey is the key of the recent post heights of a user
+func RateLimitKey(user string) []byte {
+	return prefixed(PrefixRateLimit, user)
+}
+
+// ProfileKey is the key of the profile of a user
+func ProfileKey(user string) []byte {
+	return prefixed(PrefixProfile, user)
+}
+
+// RankKey is the key of a user in the leaderboard. Keys sort by most posts,
+// then most recent post, then name, so the leaderboard is read in key order.
+func RankKey(u *User) []byte {
+	key := make([]byte, len(PrefixRank)+16, len(PrefixRank)+16+len(u.Name))
+	copy(key, PrefixRank)
+	binary.BigEndian.PutUint64(key[len(PrefixRank):], math.MaxUint64-uint64(u.NumMessages))
+	binary.BigEndian.PutUint64(key[len(PrefixRank)+8:], math.MaxUint64-uint64(u.LastPostHeight))
+	return append(key, u.Name...)
+}
+
+// MetaKey is the key of a record that exists once per chain
+func MetaKey(name string) []byte {
+	return prefixed(PrefixMeta, name)
+}
+
+// Records that exist once per chain
+var (
+	AppStateKey      = MetaKey("appstate")
+	historyKey       = MetaKey("history")
+	msgCountKey      = MetaKey("msgcount")
+	postersKey       = MetaKey("posters")
+	recoveriesKey    = MetaKey("recoveries")
+	schemaVersionKey = MetaKey("schemaversion")
+	journalKey       = MetaKey("journal")
+	versionsKey      = MetaKey("versions")
+	boardsKey        = MetaKey("boards")
+	wordListKey      = MetaKey("words")
+	proposalsKey     = MetaKey("proposals")
+)
@@ -1,81 +1,292 @@
 package model
 
 import (
+	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"strings"
 
-	"github.com/dgraph-io/badger/v3"
 	"github.com/pkg/errors"
 )
 
 type BanTx struct {
 	UserName string `json:"username"`
+	Reason   string `json:"reason,omitempty"`
 }
 
+// DefaultBoard is the board a message is posted to when it does not name one
+const DefaultBoard = "general"
+
 // Message represents a message sent by a user
 type Message struct {
+	ID      uint64 `json:"id,omitempty"`
 	Sender  string `json:"sender"`
 	Message string `json:"message"`
+	Board   string `json:"board,omitempty"`
+	Edited  bool   `json:"edited,omitempty"`
+	// ReplyTo is the ID of the message this one replies to
+	ReplyTo uint64 `json:"reply_to,omitempty"`
 }
 
+// ErrMessageNotFound is returned when a user edits or deletes a message it did not post
+var ErrMessageNotFound = errors.New("message not found")
+
 type MsgHistory struct {
 	Msg string `json:"history"`
 }
 
-func AppendToChat(db *DB, message Message) (string, error) {
-	historyBytes, err := ViewDB(db.GetDB(), []byte("history"))
+// NextMessageID returns the ID for the next message and reserves it
+func NextMessageID(db KVStore) (uint64, error) {
+	var count uint64
+	countBytes, err := db.Get(msgCountKey)
+	if err != nil {
+		return 0, err
+	}
+	if len(countBytes) == 8 {
+		count = binary.BigEndian.Uint64(countBytes)
+	}
+	count++
+	countBytes = make([]byte, 8)
+	binary.BigEndian.PutUint64(countBytes, count)
+	return count, db.Set(msgCountKey, countBytes)
+}
+
+// MessageCount returns the number of message IDs given out so far
+func MessageCount(db KVStore) (uint64, error) {
+	countBytes, err := db.Get(msgCountKey)
+	if err != nil || len(countBytes) != 8 {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(countBytes), nil
+}
+
+// ImportMessages stores messages that already have their IDs, as they were
+// exported from another chain, and makes the next message ID follow count.
+// Unlike AddMessage it leaves the chat history alone.
+func ImportMessages(db KVStore, messages []Message, count uint64) error {
+	bySender := make(map[string][]Message)
+	var senders []string
+	for _, m := range messages {
+		if _, ok := bySender[m.Sender]; !ok {
+			senders = append(senders, m.Sender)
+		}
+		bySender[m.Sender] = append(bySender[m.Sender], m)
+	}
+	for _, sender := range senders {
+		messagesBytes, err := json.Marshal(bySender[sender])
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal messages to JSON")
+		}
+		if err := db.Set(MessagesKey(sender), messagesBytes); err != nil {
+			return err
+		}
+	}
+	countBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(countBytes, count)
+	return db.Set(msgCountKey, countBytes)
+}
+
+// SetHistory replaces the chat history
+func SetHistory(db KVStore, history string) error {
+	return db.Set(historyKey, []byte(history))
+}
+
+// AddMessage stores a message under its sender and appends it to the chat history.
+// Messages without an ID are given the next free one.
+func AddMessage(db KVStore, message Message) error {
+	var err error
+	if message.ID == 0 {
+		message.ID, err = NextMessageID(db)
+		if err != nil {
+			return err
+		}
+	}
+	if message.Board == "" {
+		message.Board = DefaultBoard
+	}
+	messages, err := AppendToExistingMsgs(db, message)
+	if err != nil {
+		return err
+	}
+	messagesBytes, err := json.Marshal(messages)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal messages to JSON")
+	}
+	if err := db.Set(MessagesKey(message.Sender), messagesBytes); err != nil {
+		return err
+	}
+	chatHistory, err := AppendToChat(db, message)
+	if err != nil {
+		return err
+	}
+	return db.Set(historyKey, []byte(chatHistory))
+}
+
+// EditMessage replaces the text of a message of the sender. The chat history
+// is an append-only log and keeps the original text.
+func EditMessage(db KVStore, sender string, id uint64, text string) error {
+	return updateMessages(db, sender, id, func(messages []Message, i int) []Message {
+		messages[i].Message = text
+		messages[i].Edited = true
+		return messages
+	})
+}
+
+// DeleteMessage removes a message of the sender
+func DeleteMessage(db KVStore, sender string, id uint64) error {
+	return updateMessages(db, sender, id, func(messages []Message, i int) []Message {
+		return append(messages[:i], messages[i+1:]...)
+	})
+}
+
+// HasMessage reports whether the sender posted the message with the given ID
+func HasMessage(db KVStore, sender string, id uint64) (bool, error) {
+	messages, err := GetMessagesBySender(db, sender)
+	if err == ErrNotFound {
+		return false, nil
+	}
 	if err != nil {
-		fmt.Println("Error fething history:", err)
-		return "", err
+		return false, err
+	}
+	return indexOfMessage(messages, id) >= 0, nil
+}
+
+func indexOfMessage(messages []Message, id uint64) int {
+	for i, m := range messages {
+		if id != 0 && m.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func updateMessages(db KVStore, sender string, id uint64, update func([]Message, int) []Message) error {
+	messages, err := GetMessagesBySender(db, sender)
+	if err == ErrNotFound {
+		return ErrMessageNotFound
+	}
+	if err != nil {
+		return err
+	}
+	i := indexOfMessage(messages, id)
+	if i < 0 {
+		return ErrMessageNotFound
+	}
+	messagesBytes, err := json.Marshal(update(messages, i))
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal messages to JSON")
+	}
+	return db.Set(MessagesKey(sender), messagesBytes)
+}
+
+func AppendToChat(db KVStore, message Message) (string, error) {
+	historyBytes, err := db.Get(historyKey)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read the history")
 	}
 	msgBytes := string(historyBytes)
 	msgBytes = msgBytes + "{sender:" + message.Sender + ",message:" + message.Message + "}"
 	return msgBytes, nil
 }
 
-func FetchHistory(db *DB) (string, error) {
-	historyBytes, err := ViewDB(db.GetDB(), []byte("history"))
+// HistoryEntry is a message as the chat history recorded it
+type HistoryEntry struct {
+	Sender  string `json:"sender"`
+	Message string `json:"message"`
+}
+
+// HistoryPage is a page of the chat history, oldest entries first
+type HistoryPage struct {
+	Page    int            `json:"page"`
+	Limit   int            `json:"limit"`
+	Total   int            `json:"total"`
+	Entries []HistoryEntry `json:"entries"`
+}
+
+const historyEntryPrefix = "{sender:"
+
+// ParseHistory splits the chat history into its entries. Entries are written
+// as {sender:<name>,message:<text>}; names can't contain ',', and an entry
+// ends where the next one starts.
+func ParseHistory(history string) []HistoryEntry {
+	var entries []HistoryEntry
+	for len(history) > 0 {
+		if !strings.HasPrefix(history, historyEntryPrefix) {
+			break
+		}
+		history = history[len(historyEntryPrefix):]
+		end := strings.Index(history, "}"+historyEntryPrefix)
+		if end < 0 {
+			end = len(history) - 1
+		}
+		entry := history[:end]
+		history = history[end+1:]
+		sender, message, ok := strings.Cut(entry, ",message:")
+		if !ok {
+			continue
+		}
+		entries = append(entries, HistoryEntry{Sender: sender, Message: message})
+	}
+	return entries
+}
+
+// GetHistoryPage returns a page of the chat history. Pages start at 1.
+func GetHistoryPage(db KVStore, page int, limit int) (*HistoryPage, error) {
+	if page < 1 || limit < 1 {
+		return nil, fmt.Errorf("invalid page %d of %d entries", page, limit)
+	}
+	history, err := FetchHistory(db)
 	if err != nil {
-		fmt.Println("Error fething history:", err)
-		return "", err
+		return nil, err
 	}
-	msgHistory := string(historyBytes)
+	entries := ParseHistory(history)
+	result := &HistoryPage{Page: page, Limit: limit, Total: len(entries), Entries: []HistoryEntry{}}
+	start := (page - 1) * limit
+	if start < len(entries) {
+		end := start + limit
+		if end > len(entries) {
+			end = len(entries)
+		}
+		result.Entries = entries[start:end]
+	}
+	return result, nil
+}
 
+func FetchHistory(db KVStore) (string, error) {
+	historyBytes, err := db.Get(historyKey)
 	if err != nil {
-		fmt.Println("erro appending history: ", err)
+		return "", errors.Wrap(err, "failed to read the history")
 	}
-	return msgHistory, err
+	return string(historyBytes), nil
 }
 
-func AppendToExistingMsgs(db *DB, message Message) (string, error) {
+func AppendToExistingMsgs(db KVStore, message Message) ([]Message, error) {
 	existingMessages, err := GetMessagesBySender(db, message.Sender)
-	if err != nil && err != badger.ErrKeyNotFound {
-		return "", err
+	if err != nil && err != ErrNotFound {
+		return nil, err
 	}
-	if err == badger.ErrKeyNotFound {
-		return message.Message, nil
-	}
-	return existingMessages + ";" + message.Message, nil
+	return append(existingMessages, message), nil
 }
 
 // GetMessagesBySender retrieves all messages sent by a specific sender
-// Get Message using String
-func GetMessagesBySender(db *DB, sender string) (string, error) {
-	var messages string
-	err := db.db.View(func(txn *badger.Txn) error {
-		item, err := txn.Get([]byte(sender + "msg"))
-		if err != nil {
-			return err
-		}
-		value, err := item.ValueCopy(nil)
-		if err != nil {
-			return err
-		}
-		messages = string(value)
-		return nil
-	})
+func GetMessagesBySender(db KVStore, sender string) ([]Message, error) {
+	value, err := db.Get(MessagesKey(sender))
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	if value == nil {
+		return nil, ErrNotFound
+	}
+	var messages []Message
+	if !strings.HasPrefix(string(value), "[") {
+		// Messages written before they were stored as JSON are separated by ';'
+		for _, text := range strings.Split(string(value), ";") {
+			messages = append(messages, Message{Sender: sender, Message: text, Board: DefaultBoard})
+		}
+		return messages, nil
+	}
+	if err := json.Unmarshal(value, &messages); err != nil {
+		return nil, err
 	}
 	return messages, nil
 }
@@ -0,0 +1,139 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Migration rewrites the stored records from the layout of the previous
+// schema version to the layout of Version. Migrations only go through the
+// KVStore, so they can run on a Cache and be discarded in a dry run, or be
+// staged with the writes of a block.
+type Migration struct {
+	Version     int
+	Description string
+	Migrate     func(store KVStore) error
+	// Startup migrations move records to other keys. The code can't read the
+	// data before they ran, so they always run when the node starts.
+	Startup bool
+}
+
+var migrations = make(map[int]Migration)
+
+// RegisterMigration adds a migration to the schema version it names. It is
+// meant to be called from init functions, and panics on a duplicate version.
+func RegisterMigration(m Migration) {
+	if m.Version < 1 {
+		panic(fmt.Sprintf("invalid schema version %d", m.Version))
+	}
+	if _, ok := migrations[m.Version]; ok {
+		panic(fmt.Sprintf("migration to schema version %d registered twice", m.Version))
+	}
+	migrations[m.Version] = m
+}
+
+// LatestSchemaVersion is the layout of the records written by this code
+func LatestSchemaVersion() int {
+	latest := 0
+	for version := range migrations {
+		if version > latest {
+			latest = version
+		}
+	}
+	return latest
+}
+
+// GetSchemaVersion returns the schema version of the store. A store that
+// never recorded one is at version 0.
+func GetSchemaVersion(store KVStore) (int, error) {
+	value, err := store.Get(schemaVersionKey)
+	if err == nil && value == nil {
+		// before schema version 2 keys had no prefix
+		value, err = store.Get(legacySchemaVersionKey)
+	}
+	if err != nil || value == nil {
+		return 0, err
+	}
+	return strconv.Atoi(string(value))
+}
+
+func SetSchemaVersion(store KVStore, version int) error {
+	return store.Set(schemaVersionKey, []byte(strconv.Itoa(version)))
+}
+
+// PendingMigrations returns the migrations the store needs to reach the
+// latest schema version, in order.
+func PendingMigrations(store KVStore) ([]Migration, error) {
+	current, err := GetSchemaVersion(store)
+	if err != nil {
+		return nil, err
+	}
+	latest := LatestSchemaVersion()
+	if current > latest {
+		return nil, fmt.Errorf("database schema version %d is newer than this binary supports (%d)", current, latest)
+	}
+	pending := make([]Migration, 0, latest-current)
+	for version := current + 1; version <= latest; version++ {
+		m, ok := migrations[version]
+		if !ok {
+			return nil, fmt.Errorf("no migration to schema version %d", version)
+		}
+		pending = append(pending, m)
+	}
+	return pending, nil
+}
+
+// StartupRequired reports whether the migrations have to run before the node starts
+func StartupRequired(pending []Migration) bool {
+	for _, m := range pending {
+		if m.Startup {
+			return true
+		}
+	}
+	return false
+}
+
+// Migrate runs the pending migrations on the store in order and records the
+// new schema version. It returns the migrations it ran.
+func Migrate(store KVStore) ([]Migration, error) {
+	pending, err := PendingMigrations(store)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range pending {
+		if err := m.Migrate(store); err != nil {
+			return nil, fmt.Errorf("migration to schema version %d failed: %w", m.Version, err)
+		}
+		if err := SetSchemaVersion(store, m.Version); err != nil {
+			return nil, err
+		}
+	}
+	return pending, nil
+}
+
+// Migrate runs the pending migrations and writes their changes in one batch
+func (db *DB) Migrate() ([]Migration, error) {
+	cache := db.NewCache()
+	applied, err := Migrate(cache)
+	if err != nil {
+		return nil, err
+	}
+	if err := cache.Write(); err != nil {
+		return nil, err
+	}
+	for _, m := range applied {
+		db.logger.Info("migrated data", "version", m.Version, "description", m.Description)
+	}
+	return applied, nil
+}
+
+// DryRunMigrations runs the pending migrations without writing anything, and
+// returns the changes they would make.
+func (db *DB) DryRunMigrations() ([]Migration, []Change, error) {
+	cache := db.NewCache()
+	applied, err := Migrate(cache)
+	if err != nil {
+		return nil, nil, err
+	}
+	return applied, cache.Changes(), nil
+}
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// Size limits of a profile
+const (
+	MaxDisplayNameLength = 64
+	MaxBioLength         = 512
+	MaxLinks             = 5
+	MaxLinkLength        = 256
+	// AvatarHashLength is the length of the hex encoded SHA-256 hash of the avatar
+	AvatarHashLength = 64
+)
+
+// Profile is what a user tells about itself. The avatar image is not stored
+// on chain, only its SHA-256 content hash, so front ends can fetch it from
+// any content addressed store and check it.
+type Profile struct {
+	DisplayName string   `json:"display_name,omitempty"`
+	Bio         string   `json:"bio,omitempty"`
+	Links       []string `json:"links,omitempty"`
+	Avatar      string   `json:"avatar,omitempty"`
+}
+
+func (p Profile) Validate() error {
+	if len(p.DisplayName) > MaxDisplayNameLength {
+		return fmt.Errorf("display name is longer than %d bytes", MaxDisplayNameLength)
+	}
+	if len(p.Bio) > MaxBioLength {
+		return fmt.Errorf("bio is longer than %d bytes", MaxBioLength)
+	}
+	if len(p.Links) > MaxLinks {
+		return fmt.Errorf("profile has more than %d links", MaxLinks)
+	}
+	for _, link := range p.Links {
+		if len(link) > MaxLinkLength {
+			return fmt.Errorf("link is longer than %d bytes", MaxLinkLength)
+		}
+		u, err := url.Parse(link)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid link %q", link)
+		}
+	}
+	if p.Avatar != "" {
+		if _, err := hex.DecodeString(p.Avatar); err != nil || len(p.Avatar) != AvatarHashLength {
+			return fmt.Errorf("avatar must be a hex encoded SHA-256 hash")
+		}
+	}
+	return nil
+}
+
+// Text returns the fields of the profile that are shown as text
+func (p Profile) Text() []string {
+	text := make([]string, 0, len(p.Links)+2)
+	for _, field := range append([]string{p.DisplayName, p.Bio}, p.Links...) {
+		if field != "" {
+			text = append(text, field)
+		}
+	}
+	return text
+}
+
+// GetProfile returns the profile of the user, empty if it never set one
+func GetProfile(db KVStore, user string) (*Profile, error) {
+	var profile Profile
+	value, err := db.Get(ProfileKey(user))
+	if err != nil || value == nil {
+		return &profile, err
+	}
+	return &profile, json.Unmarshal(value, &profile)
+}
+
+func SetProfile(db KVStore, user string, profile *Profile) error {
+	value, err := json.Marshal(profile)
+	if err != nil {
+		return err
+	}
+	return db.Set(ProfileKey(user), value)
+}
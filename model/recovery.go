@@ -0,0 +1,47 @@
+package model
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// PendingRecoveries returns the names of the users with a pending recovery,
+// in order
+func PendingRecoveries(db KVStore) ([]string, error) {
+	value, err := db.Get(recoveriesKey)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	var names []string
+	if err := json.Unmarshal(value, &names); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// SetPendingRecoveries stores the names of the users with a pending recovery
+func SetPendingRecoveries(db KVStore, names []string) error {
+	if len(names) == 0 {
+		return db.Delete(recoveriesKey)
+	}
+	sort.Strings(names)
+	value, err := json.Marshal(names)
+	if err != nil {
+		return err
+	}
+	return db.Set(recoveriesKey, value)
+}
+
+// AddPendingRecovery records that the user has a pending recovery
+func AddPendingRecovery(db KVStore, name string) error {
+	names, err := PendingRecoveries(db)
+	if err != nil {
+		return err
+	}
+	for _, n := range names {
+		if n == name {
+			return nil
+		}
+	}
+	return SetPendingRecoveries(db, append(names, name))
+}
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"strings"
+)
+
+// Migrations work on the layout of the version they start from, so they
+// don't use the key builders and record helpers, which follow the latest one.
+// In schema versions 0 and 1 users are stored under their name, messages
+// under name+"msg" and the message counter under "msgcount".
+
+func init() {
+	RegisterMigration(Migration{
+		Version:     1,
+		Description: "store the messages kept as ';' separated text as JSON with IDs, and record the schema version of users",
+		Migrate:     migrateV1,
+	})
+}
+
+func migrateV1(store KVStore) error {
+	// keys and values of the messages still kept as text, in key order
+	var legacyKeys, legacyValues []string
+	var users []*User
+	err := store.Iterate(nil, func(key, value []byte) error {
+		if len(value) == 0 {
+			return nil
+		}
+		if value[0] == '{' {
+			var u User
+			if json.Unmarshal(value, &u) == nil && u.Name == string(key) && u.SchemaVersion < 1 {
+				users = append(users, &u)
+			}
+			return nil
+		}
+		if strings.HasSuffix(string(key), "msg") && !bytes.HasPrefix(value, []byte("[")) {
+			legacyKeys = append(legacyKeys, string(key))
+			legacyValues = append(legacyValues, string(value))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, u := range users {
+		u.SchemaVersion = 1
+		userBytes, err := json.Marshal(u)
+		if err != nil {
+			return err
+		}
+		if err := store.Set([]byte(u.Name), userBytes); err != nil {
+			return err
+		}
+	}
+	if len(legacyKeys) == 0 {
+		return nil
+	}
+
+	var count uint64
+	countBytes, err := store.Get([]byte("msgcount"))
+	if err != nil {
+		return err
+	}
+	if len(countBytes) == 8 {
+		count = binary.BigEndian.Uint64(countBytes)
+	}
+	// IDs are assigned in key order, so every node gets the same ones
+	for i, key := range legacyKeys {
+		sender := strings.TrimSuffix(key, "msg")
+		var messages []Message
+		for _, text := range strings.Split(legacyValues[i], ";") {
+			count++
+			messages = append(messages, Message{ID: count, Sender: sender, Message: text, Board: DefaultBoard})
+		}
+		messagesBytes, err := json.Marshal(messages)
+		if err != nil {
+			return err
+		}
+		if err := store.Set([]byte(key), messagesBytes); err != nil {
+			return err
+		}
+	}
+	countBytes = make([]byte, 8)
+	binary.BigEndian.PutUint64(countBytes, count)
+	return store.Set([]byte("msgcount"), countBytes)
+}
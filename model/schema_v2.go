@@ -0,0 +1,89 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// Keys of schema version 1 that have no prefix
+var (
+	legacySchemaVersionKey = []byte("schemaversion")
+	legacyJournalKey       = []byte("journal")
+	legacyMetaKeys         = map[string]struct{}{
+		"appstate":   {},
+		"history":    {},
+		"msgcount":   {},
+		"posters":    {},
+		"recoveries": {},
+	}
+)
+
+func init() {
+	RegisterMigration(Migration{
+		Version:     2,
+		Description: "move every record under the prefix of its type",
+		Migrate:     migrateV2,
+		Startup:     true,
+	})
+}
+
+func migrateV2(store KVStore) error {
+	var from, to, values [][]byte
+	err := store.Iterate(nil, func(key, value []byte) error {
+		newKey, newValue := v2Record(key, value)
+		if newKey != nil {
+			from = append(from, key)
+			to = append(to, newKey)
+			values = append(values, newValue)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for i := range from {
+		if err := store.Delete(from[i]); err != nil {
+			return err
+		}
+		if err := store.Set(to[i], values[i]); err != nil {
+			return err
+		}
+	}
+	return store.Delete(legacySchemaVersionKey)
+}
+
+// v2Record returns the key and value of a record of schema version 1 in
+// schema version 2, or a nil key for records that are not moved.
+// In version 1 a user named like a system record, or like another user
+// followed by "msg", overwrote it; such records can't be told apart anymore
+// and are taken as users.
+func v2Record(key, value []byte) ([]byte, []byte) {
+	name := string(key)
+	if bytes.HasPrefix(value, []byte("{")) {
+		var u User
+		if json.Unmarshal(value, &u) == nil && u.Name == name {
+			u.SchemaVersion = 2
+			userBytes, err := json.Marshal(u)
+			if err == nil {
+				return UserKey(name), userBytes
+			}
+		}
+	}
+	if _, ok := legacyMetaKeys[name]; ok {
+		return MetaKey(name), value
+	}
+	switch {
+	case strings.HasSuffix(name, "msg") && bytes.HasPrefix(value, []byte("[")):
+		return MessagesKey(strings.TrimSuffix(name, "msg")), value
+	case strings.HasSuffix(name, "bal") && len(value) == 8:
+		return BalanceKey(strings.TrimSuffix(name, "bal")), value
+	case strings.HasSuffix(name, "rate") && bytes.HasPrefix(value, []byte("[")):
+		return RateLimitKey(strings.TrimSuffix(name, "rate")), value
+	case strings.HasSuffix(name, "profile") && bytes.HasPrefix(value, []byte("{")):
+		return ProfileKey(strings.TrimSuffix(name, "profile")), value
+	case strings.HasPrefix(name, "val") && !strings.HasPrefix(name, PrefixValidator):
+		return ValidatorKey(key[len("val"):]), value
+	}
+	return nil, nil
+}
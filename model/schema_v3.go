@@ -0,0 +1,56 @@
+package model
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+)
+
+func init() {
+	RegisterMigration(Migration{
+		Version:     3,
+		Description: "index the users that posted by rank, replacing the list of posters, and record the schema version of users",
+		Migrate:     migrateV3,
+		Startup:     true,
+	})
+}
+
+// migrateV3 records the schema version of users and adds every user that posted to the leaderboard index, under
+// "rank/", followed by the inverted number of posts and height of the last
+// post and the name, and removes the sorted list of posters of version 2
+func migrateV3(store KVStore) error {
+	var keys, values [][]byte
+	err := store.Iterate([]byte("user/"), func(userKey, value []byte) error {
+		var u User
+		if err := json.Unmarshal(value, &u); err != nil {
+			return err
+		}
+		u.SchemaVersion = 3
+		userBytes, err := json.Marshal(u)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, userKey)
+		values = append(values, userBytes)
+		if u.NumMessages == 0 {
+			return nil
+		}
+		key := bytes.NewBufferString("rank/")
+		_ = binary.Write(key, binary.BigEndian, math.MaxUint64-uint64(u.NumMessages))
+		_ = binary.Write(key, binary.BigEndian, math.MaxUint64-uint64(u.LastPostHeight))
+		key.WriteString(u.Name)
+		keys = append(keys, key.Bytes())
+		values = append(values, []byte(u.Name))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for i := range keys {
+		if err := store.Set(keys[i], values[i]); err != nil {
+			return err
+		}
+	}
+	return store.Delete([]byte("meta/posters"))
+}
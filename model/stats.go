@@ -0,0 +1,78 @@
+package model
+
+import "errors"
+
+// UserStats is the activity of a user
+type UserStats struct {
+	Name            string `json:"name"`
+	Posts           int64  `json:"posts"`
+	FirstPostHeight int64  `json:"first_post_height,omitempty"`
+	LastPostHeight  int64  `json:"last_post_height,omitempty"`
+	Strikes         int64  `json:"strikes"`
+	Edits           int64  `json:"edits"`
+	Deletions       int64  `json:"deletions"`
+}
+
+func (u *User) Stats() UserStats {
+	return UserStats{
+		Name:            u.Name,
+		Posts:           u.NumMessages,
+		FirstPostHeight: u.FirstPostHeight,
+		LastPostHeight:  u.LastPostHeight,
+		Strikes:         u.Strikes,
+		Edits:           u.Edits,
+		Deletions:       u.Deletions,
+	}
+}
+
+// RecordPost counts a post of the user made at the given height, and moves
+// the user to its new place in the leaderboard. The caller stores the user.
+func RecordPost(db KVStore, u *User, height int64) error {
+	if u.NumMessages > 0 {
+		if err := db.Delete(RankKey(u)); err != nil {
+			return err
+		}
+	}
+	u.RecordPost(height)
+	return SetRank(db, u)
+}
+
+// RecordPost counts a post of the user made at the given height
+func (u *User) RecordPost(height int64) {
+	if u.NumMessages == 0 {
+		u.FirstPostHeight = height
+	}
+	u.NumMessages++
+	u.LastPostHeight = height
+}
+
+// SetRank adds a user that posted to the leaderboard
+func SetRank(db KVStore, u *User) error {
+	return db.Set(RankKey(u), []byte(u.Name))
+}
+
+// Leaderboard returns the stats of the most active users, by number of posts
+// and then by most recent post. It returns at most limit entries, reading
+// only as many users.
+func Leaderboard(db KVStore, limit int) ([]UserStats, error) {
+	var names []string
+	err := db.Iterate([]byte(PrefixRank), func(_, value []byte) error {
+		if len(names) == limit {
+			return errStopIteration
+		}
+		names = append(names, string(value))
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStopIteration) {
+		return nil, err
+	}
+	stats := make([]UserStats, 0, len(names))
+	for _, name := range names {
+		u, err := GetUser(db, name)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, u.Stats())
+	}
+	return stats, nil
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotFound is returned when a record does not exist
+var ErrNotFound = errors.New("not found")
+
+// Store is the key-value database the forum state is kept in. DB works on top
+// of any Store, so the storage engine can be chosen in app.toml.
+type Store interface {
+	KVStore
+	// NewBatch stages writes that are applied together by Batch.Write
+	NewBatch() Batch
+	// Snapshot returns a read-only view of the store as it is now, which
+	// later writes don't change. It must be released when done.
+	Snapshot() (Snapshot, error)
+	Close() error
+}
+
+// Batch is a set of writes applied at once
+type Batch interface {
+	Set(key, value []byte) error
+	Delete(key []byte) error
+	Write() error
+	// Discard releases the batch; it does nothing after Write
+	Discard()
+}
+
+// Snapshot is a read-only view of a Store at one point in time
+type Snapshot interface {
+	Get(key []byte) ([]byte, error)
+	Iterate(prefix []byte, fn func(key, value []byte) error) error
+	Release()
+}
+
+// Storage backends selectable with db_backend in app.toml. Any other name is
+// passed to cometbft-db, which knows "goleveldb" and "memdb", and the other
+// engines it was built with (e.g. "cleveldb" or "rocksdb" with their build tags).
+const (
+	BackendBadger    = "badger"
+	BackendGoLevelDB = "goleveldb"
+	BackendMemory    = "memory"
+)
+
+// OpenStore opens the store of the given backend in dir
+func OpenStore(backend string, dir string) (Store, error) {
+	switch backend {
+	case BackendBadger, "":
+		return OpenBadgerStore(dir)
+	case BackendMemory:
+		return NewMemoryStore(), nil
+	default:
+		store, err := OpenCometDBStore(backend, dir)
+		if err != nil {
+			return nil, fmt.Errorf("unknown database backend %q: %w", backend, err)
+		}
+		return store, nil
+	}
+}
+
+// prefixEnd returns the first key after all the keys with the prefix, or nil
+// if there is none
+func prefixEnd(prefix []byte) []byte {
+	end := append([]byte(nil), prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
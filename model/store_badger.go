@@ -0,0 +1,130 @@
+package model
+
+import (
+	"github.com/dgraph-io/badger/v3"
+)
+
+// badgerStore keeps the data in Badger
+type badgerStore struct {
+	db *badger.DB
+}
+
+// OpenBadgerStore opens or creates a Badger database in dir
+func OpenBadgerStore(dir string) (Store, error) {
+	db, err := badger.Open(badger.DefaultOptions(dir))
+	if err != nil {
+		return nil, err
+	}
+	return NewBadgerStore(db), nil
+}
+
+// NewBadgerStore uses an open Badger database as a Store
+func NewBadgerStore(db *badger.DB) Store {
+	return &badgerStore{db: db}
+}
+
+func (s *badgerStore) Get(key []byte) ([]byte, error) {
+	var value []byte
+	err := s.db.View(func(txn *badger.Txn) error {
+		var err error
+		value, err = badgerGet(txn, key)
+		return err
+	})
+	return value, err
+}
+
+func badgerGet(txn *badger.Txn, key []byte) ([]byte, error) {
+	item, err := txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return item.ValueCopy(nil)
+}
+
+func (s *badgerStore) Set(key, value []byte) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(key, value)
+	})
+}
+
+func (s *badgerStore) Delete(key []byte) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(key)
+	})
+}
+
+func (s *badgerStore) Iterate(prefix []byte, fn func(key, value []byte) error) error {
+	return s.db.View(func(txn *badger.Txn) error {
+		return badgerIterate(txn, prefix, fn)
+	})
+}
+
+func badgerIterate(txn *badger.Txn, prefix []byte, fn func(key, value []byte) error) error {
+	it := txn.NewIterator(badger.DefaultIteratorOptions)
+	defer it.Close()
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		item := it.Item()
+		value, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		if err := fn(item.KeyCopy(nil), value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewBatch uses a Badger write batch. Write batches are not bound by the
+// transaction size limit, but are not atomic either; see Cache.Write.
+func (s *badgerStore) NewBatch() Batch {
+	return &badgerBatch{wb: s.db.NewWriteBatch()}
+}
+
+func (s *badgerStore) Snapshot() (Snapshot, error) {
+	return &badgerSnapshot{txn: s.db.NewTransaction(false)}, nil
+}
+
+func (s *badgerStore) Close() error {
+	return s.db.Close()
+}
+
+type badgerBatch struct {
+	wb *badger.WriteBatch
+}
+
+func (b *badgerBatch) Set(key, value []byte) error {
+	return b.wb.Set(key, value)
+}
+
+func (b *badgerBatch) Delete(key []byte) error {
+	return b.wb.Delete(key)
+}
+
+func (b *badgerBatch) Write() error {
+	return b.wb.Flush()
+}
+
+func (b *badgerBatch) Discard() {
+	b.wb.Cancel()
+}
+
+// badgerSnapshot is a read-only Badger transaction
+type badgerSnapshot struct {
+	txn *badger.Txn
+}
+
+func (s *badgerSnapshot) Get(key []byte) ([]byte, error) {
+	return badgerGet(s.txn, key)
+}
+
+func (s *badgerSnapshot) Iterate(prefix []byte, fn func(key, value []byte) error) error {
+	return badgerIterate(s.txn, prefix, fn)
+}
+
+func (s *badgerSnapshot) Release() {
+	s.txn.Discard()
+}
@@ -0,0 +1,142 @@
+package model
+
+import (
+	"errors"
+
+	dbm "github.com/cometbft/cometbft-db"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// ErrSnapshotsUnsupported is returned by stores that can't take snapshots
+var ErrSnapshotsUnsupported = errors.New("the database backend does not support snapshots")
+
+// cometDBStore keeps the data in one of the databases of cometbft-db, the
+// ones CometBFT itself uses
+type cometDBStore struct {
+	db dbm.DB
+}
+
+// OpenCometDBStore opens or creates a cometbft-db database of the given backend in dir
+func OpenCometDBStore(backend string, dir string) (Store, error) {
+	db, err := dbm.NewDB("forum", dbm.BackendType(backend), dir)
+	if err != nil {
+		return nil, err
+	}
+	return NewCometDBStore(db), nil
+}
+
+// NewCometDBStore uses an open cometbft-db database as a Store
+func NewCometDBStore(db dbm.DB) Store {
+	return &cometDBStore{db: db}
+}
+
+func (s *cometDBStore) Get(key []byte) ([]byte, error) {
+	return s.db.Get(key)
+}
+
+func (s *cometDBStore) Set(key, value []byte) error {
+	// cometbft-db rejects nil values
+	if value == nil {
+		value = []byte{}
+	}
+	return s.db.Set(key, value)
+}
+
+func (s *cometDBStore) Delete(key []byte) error {
+	return s.db.Delete(key)
+}
+
+func (s *cometDBStore) Iterate(prefix []byte, fn func(key, value []byte) error) error {
+	var start []byte
+	if len(prefix) > 0 {
+		start = prefix
+	}
+	it, err := s.db.Iterator(start, prefixEnd(prefix))
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+	for ; it.Valid(); it.Next() {
+		key := append([]byte{}, it.Key()...)
+		value := append([]byte{}, it.Value()...)
+		if err := fn(key, value); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
+
+func (s *cometDBStore) NewBatch() Batch {
+	return &cometDBBatch{batch: s.db.NewBatch()}
+}
+
+// Snapshot is only supported by goleveldb
+func (s *cometDBStore) Snapshot() (Snapshot, error) {
+	goleveldb, ok := s.db.(*dbm.GoLevelDB)
+	if !ok {
+		return nil, ErrSnapshotsUnsupported
+	}
+	snap, err := goleveldb.DB().GetSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &levelDBSnapshot{snap: snap}, nil
+}
+
+func (s *cometDBStore) Close() error {
+	return s.db.Close()
+}
+
+type cometDBBatch struct {
+	batch dbm.Batch
+}
+
+func (b *cometDBBatch) Set(key, value []byte) error {
+	if value == nil {
+		value = []byte{}
+	}
+	return b.batch.Set(key, value)
+}
+
+func (b *cometDBBatch) Delete(key []byte) error {
+	return b.batch.Delete(key)
+}
+
+func (b *cometDBBatch) Write() error {
+	return b.batch.Write()
+}
+
+func (b *cometDBBatch) Discard() {
+	// closing a batch that was written does nothing
+	_ = b.batch.Close()
+}
+
+type levelDBSnapshot struct {
+	snap *leveldb.Snapshot
+}
+
+func (s *levelDBSnapshot) Get(key []byte) ([]byte, error) {
+	value, err := s.snap.Get(key, nil)
+	if err == leveldb.ErrNotFound {
+		return nil, nil
+	}
+	return value, err
+}
+
+func (s *levelDBSnapshot) Iterate(prefix []byte, fn func(key, value []byte) error) error {
+	it := s.snap.NewIterator(util.BytesPrefix(prefix), nil)
+	defer it.Release()
+	for it.Next() {
+		key := append([]byte{}, it.Key()...)
+		value := append([]byte{}, it.Value()...)
+		if err := fn(key, value); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
+
+func (s *levelDBSnapshot) Release() {
+	s.snap.Release()
+}
@@ -0,0 +1,139 @@
+package model
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+)
+
+// memoryStore keeps the data in memory, for tests and benchmarks
+type memoryStore struct {
+	mtx  sync.RWMutex
+	data map[string][]byte
+}
+
+func NewMemoryStore() Store {
+	return &memoryStore{data: make(map[string][]byte)}
+}
+
+func (s *memoryStore) Get(key []byte) ([]byte, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	value, ok := s.data[string(key)]
+	if !ok {
+		return nil, nil
+	}
+	return append([]byte{}, value...), nil
+}
+
+func (s *memoryStore) Set(key, value []byte) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.data[string(key)] = append([]byte{}, value...)
+	return nil
+}
+
+func (s *memoryStore) Delete(key []byte) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	delete(s.data, string(key))
+	return nil
+}
+
+// Iterate works on a copy of the matching records, so fn may write to the store
+func (s *memoryStore) Iterate(prefix []byte, fn func(key, value []byte) error) error {
+	s.mtx.RLock()
+	snapshot := s.copy(prefix)
+	s.mtx.RUnlock()
+	return snapshot.Iterate(prefix, fn)
+}
+
+func (s *memoryStore) copy(prefix []byte) *memorySnapshot {
+	snapshot := &memorySnapshot{data: make(map[string][]byte)}
+	for key, value := range s.data {
+		if bytes.HasPrefix([]byte(key), prefix) {
+			snapshot.data[key] = value
+		}
+	}
+	return snapshot
+}
+
+func (s *memoryStore) NewBatch() Batch {
+	return &memoryBatch{store: s}
+}
+
+func (s *memoryStore) Snapshot() (Snapshot, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	return s.copy(nil), nil
+}
+
+func (s *memoryStore) Close() error {
+	return nil
+}
+
+// memoryBatch applies its changes under a single lock
+type memoryBatch struct {
+	store   *memoryStore
+	changes []Change
+}
+
+func (b *memoryBatch) Set(key, value []byte) error {
+	b.changes = append(b.changes, Change{Key: key, Value: append([]byte{}, value...)})
+	return nil
+}
+
+func (b *memoryBatch) Delete(key []byte) error {
+	b.changes = append(b.changes, Change{Key: key, Delete: true})
+	return nil
+}
+
+func (b *memoryBatch) Write() error {
+	b.store.mtx.Lock()
+	defer b.store.mtx.Unlock()
+	for _, ch := range b.changes {
+		if ch.Delete {
+			delete(b.store.data, string(ch.Key))
+		} else {
+			b.store.data[string(ch.Key)] = ch.Value
+		}
+	}
+	b.changes = nil
+	return nil
+}
+
+func (b *memoryBatch) Discard() {
+	b.changes = nil
+}
+
+// memorySnapshot is a copy of the records; values are never modified in
+// place, so they are shared with the store
+type memorySnapshot struct {
+	data map[string][]byte
+}
+
+func (s *memorySnapshot) Get(key []byte) ([]byte, error) {
+	value, ok := s.data[string(key)]
+	if !ok {
+		return nil, nil
+	}
+	return append([]byte{}, value...), nil
+}
+
+func (s *memorySnapshot) Iterate(prefix []byte, fn func(key, value []byte) error) error {
+	keys := make([]string, 0, len(s.data))
+	for key := range s.data {
+		if bytes.HasPrefix([]byte(key), prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := fn([]byte(key), append([]byte{}, s.data[key]...)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *memorySnapshot) Release() {}
@@ -0,0 +1,458 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/cometbft/cometbft/crypto"
+	"github.com/cometbft/cometbft/crypto/ed25519"
+	"github.com/pkg/errors"
+)
+
+// Types of the transactions sent as a Tx
+const (
+	TxTypeRegister = "register"
+	TxTypePost     = "post"
+	TxTypeTransfer = "transfer"
+	TxTypeProfile  = "profile"
+	TxTypeEdit     = "edit"
+	TxTypeDelete   = "delete"
+	TxTypeFlag     = "flag"
+	// Bans of users by moderators; proposers ban with a BanTx
+	TxTypeBanUser = "ban_user"
+	// Bans are appealed by the banned user and resolved by moderators
+	TxTypeAppeal        = "appeal"
+	TxTypeResolveAppeal = "resolve_appeal"
+	// Key management
+	TxTypeRotateKey      = "rotate_key"
+	TxTypeSetRecovery    = "set_recovery"
+	TxTypeRecover        = "recover"
+	TxTypeCancelRecovery = "cancel_recovery"
+	// Administration, sent by admins
+	TxTypeSetModerator = "set_moderator"
+	TxTypeWordList     = "word_list"
+	// Upgrades, voted by moderators
+	TxTypeProposeUpgrade = "propose_upgrade"
+	TxTypeApproveUpgrade = "approve_upgrade"
+)
+
+// Tx is a typed transaction signed by its sender. The nonce must match the
+// number of transactions the sender made before, so a transaction can't be
+// replayed.
+type Tx struct {
+	Type      string          `json:"type"`
+	Sender    string          `json:"sender"`
+	Nonce     uint64          `json:"nonce"`
+	Data      json.RawMessage `json:"data"`
+	Signature []byte          `json:"signature,omitempty"`
+}
+
+// RegisterTx creates the account of the sender with the given ed25519 public
+// key. It must be signed with the matching private key.
+type RegisterTx struct {
+	PubKey      []byte `json:"pub_key"`
+	RecoveryKey []byte `json:"recovery_key,omitempty"`
+}
+
+// PostTx posts a message on a board, or a reply to another message
+type PostTx struct {
+	Message string `json:"message"`
+	Board   string `json:"board,omitempty"`
+	ReplyTo uint64 `json:"reply_to,omitempty"`
+}
+
+// EditTx replaces the text of a message of the sender
+type EditTx struct {
+	MessageID uint64 `json:"message_id"`
+	Message   string `json:"message"`
+}
+
+// DeleteTx deletes a message of the sender
+type DeleteTx struct {
+	MessageID uint64 `json:"message_id"`
+}
+
+// FlagTx reports a message as inappropriate
+type FlagTx struct {
+	MessageID uint64 `json:"message_id"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// BanUserTx bans a user, sent by a moderator
+type BanUserTx struct {
+	User   string `json:"user"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// AppealTx asks the moderators to lift the ban of the sender. It is the only
+// transaction a banned user can send.
+type AppealTx struct {
+	Reason string `json:"reason"`
+}
+
+// ResolveAppealTx closes the appeal of a user, lifting its ban if accepted
+type ResolveAppealTx struct {
+	User   string `json:"user"`
+	Accept bool   `json:"accept"`
+}
+
+// TransferTx moves tokens from the sender of the Tx to another user
+type TransferTx struct {
+	To     string `json:"to"`
+	Amount uint64 `json:"amount"`
+}
+
+// RotateKeyTx replaces the key of the sender. It is signed with the current key.
+type RotateKeyTx struct {
+	PubKey []byte `json:"pub_key"`
+}
+
+// SetRecoveryTx sets the recovery key of the sender, or removes it if empty
+type SetRecoveryTx struct {
+	RecoveryKey []byte `json:"recovery_key,omitempty"`
+}
+
+// RecoverTx requests or approves a reset of the key of a user. It is sent
+// either by the user itself and signed with its recovery key, or by a
+// moderator.
+type RecoverTx struct {
+	User   string `json:"user"`
+	PubKey []byte `json:"pub_key"`
+}
+
+// CancelRecoveryTx cancels a pending recovery of the account of the sender.
+// It is signed with the current key.
+type CancelRecoveryTx struct{}
+
+// SetModeratorTx makes a user a moderator, or removes its moderator role
+type SetModeratorTx struct {
+	User      string `json:"user"`
+	Moderator bool   `json:"moderator"`
+}
+
+// WordListTx adds words to the word list of the chain and removes others
+type WordListTx struct {
+	Add    []string `json:"add,omitempty"`
+	Remove []string `json:"remove,omitempty"`
+}
+
+// ProposeUpgradeTx puts an upgrade plan to the vote of the moderators
+type ProposeUpgradeTx struct {
+	Plan UpgradePlan `json:"plan"`
+}
+
+// ApproveUpgradeTx approves an upgrade proposal
+type ApproveUpgradeTx struct {
+	ProposalID uint64 `json:"proposal_id"`
+}
+
+// ParseTx decodes a typed transaction
+func ParseTx(tx []byte) (*Tx, error) {
+	var parsed Tx
+	if err := json.Unmarshal(tx, &parsed); err != nil {
+		return nil, err
+	}
+	if parsed.Type == "" {
+		return nil, errors.New("transaction is missing type")
+	}
+	if parsed.Sender == "" {
+		return nil, errors.New("transaction is missing sender")
+	}
+	return &parsed, nil
+}
+
+// NewTx builds an unsigned typed transaction with the given data
+func NewTx(txType string, sender string, nonce uint64, data interface{}) (*Tx, error) {
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{Type: txType, Sender: sender, Nonce: nonce, Data: dataBytes}, nil
+}
+
+// SignBytes returns the bytes covered by the signature: the transaction
+// without its signature.
+func (tx Tx) SignBytes() ([]byte, error) {
+	tx.Signature = nil
+	return json.Marshal(tx)
+}
+
+func (tx *Tx) Sign(key crypto.PrivKey) error {
+	signBytes, err := tx.SignBytes()
+	if err != nil {
+		return err
+	}
+	tx.Signature, err = key.Sign(signBytes)
+	return err
+}
+
+// VerifySignature reports whether the transaction was signed with the key
+func (tx *Tx) VerifySignature(pubKey ed25519.PubKey) bool {
+	if len(pubKey) != ed25519.PubKeySize {
+		return false
+	}
+	signBytes, err := tx.SignBytes()
+	if err != nil {
+		return false
+	}
+	return pubKey.VerifySignature(signBytes, tx.Signature)
+}
+
+// Bytes encodes the transaction for broadcasting
+func (tx *Tx) Bytes() ([]byte, error) {
+	return json.Marshal(tx)
+}
+
+func (tx *Tx) parseData(txType string, data interface{}) error {
+	if tx.Type != txType {
+		return fmt.Errorf("not a %s transaction: %s", txType, tx.Type)
+	}
+	return json.Unmarshal(tx.Data, data)
+}
+
+// ParseRegister decodes the data of a register transaction
+func (tx *Tx) ParseRegister() (*RegisterTx, error) {
+	var register RegisterTx
+	if err := tx.parseData(TxTypeRegister, &register); err != nil {
+		return nil, err
+	}
+	if err := validatePubKey(register.PubKey); err != nil {
+		return nil, err
+	}
+	if len(register.RecoveryKey) > 0 {
+		if err := validatePubKey(register.RecoveryKey); err != nil {
+			return nil, err
+		}
+	}
+	return &register, nil
+}
+
+func validatePubKey(pubKey []byte) error {
+	if len(pubKey) != ed25519.PubKeySize {
+		return fmt.Errorf("public key must be %d bytes", ed25519.PubKeySize)
+	}
+	return nil
+}
+
+// ParseEdit decodes the data of an edit transaction
+func (tx *Tx) ParseEdit() (*EditTx, error) {
+	var edit EditTx
+	if err := tx.parseData(TxTypeEdit, &edit); err != nil {
+		return nil, err
+	}
+	if edit.MessageID == 0 {
+		return nil, errors.New("edit is missing message_id")
+	}
+	if edit.Message == "" {
+		return nil, errors.New("edit is missing message")
+	}
+	return &edit, nil
+}
+
+// ParseDelete decodes the data of a delete transaction
+func (tx *Tx) ParseDelete() (*DeleteTx, error) {
+	var del DeleteTx
+	if err := tx.parseData(TxTypeDelete, &del); err != nil {
+		return nil, err
+	}
+	if del.MessageID == 0 {
+		return nil, errors.New("delete is missing message_id")
+	}
+	return &del, nil
+}
+
+// ParseProfile decodes the data of a profile transaction, which replaces the
+// whole profile of the sender
+func (tx *Tx) ParseProfile() (*Profile, error) {
+	var profile Profile
+	if err := tx.parseData(TxTypeProfile, &profile); err != nil {
+		return nil, err
+	}
+	if err := profile.Validate(); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// ParseRotateKey decodes the data of a rotate_key transaction
+func (tx *Tx) ParseRotateKey() (*RotateKeyTx, error) {
+	var rotate RotateKeyTx
+	if err := tx.parseData(TxTypeRotateKey, &rotate); err != nil {
+		return nil, err
+	}
+	if err := validatePubKey(rotate.PubKey); err != nil {
+		return nil, err
+	}
+	return &rotate, nil
+}
+
+// ParseSetRecovery decodes the data of a set_recovery transaction
+func (tx *Tx) ParseSetRecovery() (*SetRecoveryTx, error) {
+	var set SetRecoveryTx
+	if err := tx.parseData(TxTypeSetRecovery, &set); err != nil {
+		return nil, err
+	}
+	if len(set.RecoveryKey) > 0 {
+		if err := validatePubKey(set.RecoveryKey); err != nil {
+			return nil, err
+		}
+	}
+	return &set, nil
+}
+
+// ParseRecover decodes the data of a recover transaction
+func (tx *Tx) ParseRecover() (*RecoverTx, error) {
+	var recover RecoverTx
+	if err := tx.parseData(TxTypeRecover, &recover); err != nil {
+		return nil, err
+	}
+	if recover.User == "" {
+		return nil, errors.New("recovery is missing user")
+	}
+	if err := validatePubKey(recover.PubKey); err != nil {
+		return nil, err
+	}
+	return &recover, nil
+}
+
+// ParsePost decodes the data of a post transaction
+func (tx *Tx) ParsePost() (*PostTx, error) {
+	var post PostTx
+	if err := tx.parseData(TxTypePost, &post); err != nil {
+		return nil, err
+	}
+	if post.Message == "" {
+		return nil, errors.New("post is missing message")
+	}
+	return &post, nil
+}
+
+// ToMessage returns the message a post transaction stores
+func (p PostTx) ToMessage(sender string) Message {
+	return Message{Sender: sender, Message: p.Message, Board: p.Board, ReplyTo: p.ReplyTo}
+}
+
+// ParseFlag decodes the data of a flag transaction
+func (tx *Tx) ParseFlag() (*FlagTx, error) {
+	var flag FlagTx
+	if err := tx.parseData(TxTypeFlag, &flag); err != nil {
+		return nil, err
+	}
+	if flag.MessageID == 0 {
+		return nil, errors.New("flag is missing message_id")
+	}
+	if len(flag.Reason) > MaxReasonLength {
+		return nil, fmt.Errorf("reason must be at most %d bytes", MaxReasonLength)
+	}
+	return &flag, nil
+}
+
+// ParseBanUser decodes the data of a ban_user transaction
+func (tx *Tx) ParseBanUser() (*BanUserTx, error) {
+	var ban BanUserTx
+	if err := tx.parseData(TxTypeBanUser, &ban); err != nil {
+		return nil, err
+	}
+	if ban.User == "" {
+		return nil, errors.New("ban_user is missing user")
+	}
+	if len(ban.Reason) > MaxReasonLength {
+		return nil, fmt.Errorf("reason must be at most %d bytes", MaxReasonLength)
+	}
+	return &ban, nil
+}
+
+// ParseAppeal decodes the data of an appeal transaction
+func (tx *Tx) ParseAppeal() (*AppealTx, error) {
+	var appeal AppealTx
+	if err := tx.parseData(TxTypeAppeal, &appeal); err != nil {
+		return nil, err
+	}
+	if appeal.Reason == "" {
+		return nil, errors.New("appeal is missing reason")
+	}
+	if len(appeal.Reason) > MaxReasonLength {
+		return nil, fmt.Errorf("reason must be at most %d bytes", MaxReasonLength)
+	}
+	return &appeal, nil
+}
+
+// ParseResolveAppeal decodes the data of a resolve_appeal transaction
+func (tx *Tx) ParseResolveAppeal() (*ResolveAppealTx, error) {
+	var resolve ResolveAppealTx
+	if err := tx.parseData(TxTypeResolveAppeal, &resolve); err != nil {
+		return nil, err
+	}
+	if resolve.User == "" {
+		return nil, errors.New("resolve_appeal is missing user")
+	}
+	return &resolve, nil
+}
+
+// ParseTransfer decodes the data of a transfer transaction
+func (tx *Tx) ParseTransfer() (*TransferTx, error) {
+	var transfer TransferTx
+	if err := tx.parseData(TxTypeTransfer, &transfer); err != nil {
+		return nil, err
+	}
+	if transfer.To == "" {
+		return nil, errors.New("transfer is missing recipient")
+	}
+	if transfer.Amount == 0 {
+		return nil, errors.New("transfer amount must be positive")
+	}
+	return &transfer, nil
+}
+
+// ParseSetModerator decodes the data of a set_moderator transaction
+func (tx *Tx) ParseSetModerator() (*SetModeratorTx, error) {
+	var set SetModeratorTx
+	if err := tx.parseData(TxTypeSetModerator, &set); err != nil {
+		return nil, err
+	}
+	if set.User == "" {
+		return nil, errors.New("set_moderator is missing user")
+	}
+	return &set, nil
+}
+
+// ParseWordList decodes the data of a word_list transaction
+func (tx *Tx) ParseWordList() (*WordListTx, error) {
+	var update WordListTx
+	if err := tx.parseData(TxTypeWordList, &update); err != nil {
+		return nil, err
+	}
+	if len(update.Add) == 0 && len(update.Remove) == 0 {
+		return nil, errors.New("word_list has no words to add or remove")
+	}
+	for _, word := range append(update.Add, update.Remove...) {
+		if err := ValidateWord(word); err != nil {
+			return nil, err
+		}
+	}
+	return &update, nil
+}
+
+// ParseProposeUpgrade decodes the data of a propose_upgrade transaction
+func (tx *Tx) ParseProposeUpgrade() (*ProposeUpgradeTx, error) {
+	var propose ProposeUpgradeTx
+	if err := tx.parseData(TxTypeProposeUpgrade, &propose); err != nil {
+		return nil, err
+	}
+	if err := propose.Plan.Validate(); err != nil {
+		return nil, err
+	}
+	return &propose, nil
+}
+
+// ParseApproveUpgrade decodes the data of an approve_upgrade transaction
+func (tx *Tx) ParseApproveUpgrade() (*ApproveUpgradeTx, error) {
+	var approve ApproveUpgradeTx
+	if err := tx.parseData(TxTypeApproveUpgrade, &approve); err != nil {
+		return nil, err
+	}
+	if approve.ProposalID == 0 {
+		return nil, errors.New("approve_upgrade is missing proposal_id")
+	}
+	return &approve, nil
+}
@@ -0,0 +1,96 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// UpgradePlan names the application version the chain moves to, and the
+// height at which it does
+type UpgradePlan struct {
+	Name    string `json:"name"`
+	Version uint64 `json:"version"`
+	Height  int64  `json:"height"`
+	// Info tells operators where to get the new binary
+	Info string `json:"info,omitempty"`
+}
+
+func (p UpgradePlan) Validate() error {
+	if p.Name == "" {
+		return errors.New("upgrade is missing name")
+	}
+	if p.Version == 0 {
+		return errors.New("upgrade is missing version")
+	}
+	if p.Height <= 0 {
+		return errors.New("upgrade height must be positive")
+	}
+	return nil
+}
+
+// Status of an upgrade proposal
+const (
+	// ProposalVoting proposals wait for the approval of enough moderators
+	ProposalVoting = "voting"
+	// ProposalScheduled is the approved proposal whose plan runs at its height
+	ProposalScheduled = "scheduled"
+	// ProposalDone proposals were carried out
+	ProposalDone = "done"
+	// ProposalExpired proposals were not approved in time
+	ProposalExpired = "expired"
+	// ProposalSuperseded proposals were scheduled, then replaced by another one
+	ProposalSuperseded = "superseded"
+)
+
+// UpgradeProposal is an upgrade plan put to the vote of the moderators
+type UpgradeProposal struct {
+	ID           uint64      `json:"id"`
+	Proposer     string      `json:"proposer"`
+	Plan         UpgradePlan `json:"plan"`
+	SubmitHeight int64       `json:"submit_height"`
+	// Approvals lists the moderators that approved, the proposer first
+	Approvals []string `json:"approvals"`
+	Status    string   `json:"status"`
+}
+
+// Approve records the approval of a moderator. It reports false if the
+// moderator already approved.
+func (p *UpgradeProposal) Approve(moderator string) bool {
+	for _, m := range p.Approvals {
+		if m == moderator {
+			return false
+		}
+	}
+	p.Approvals = append(p.Approvals, moderator)
+	return true
+}
+
+// UpgradeProposals returns all upgrade proposals, by ID
+func UpgradeProposals(db KVStore) ([]UpgradeProposal, error) {
+	value, err := db.Get(proposalsKey)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	var proposals []UpgradeProposal
+	err = json.Unmarshal(value, &proposals)
+	return proposals, err
+}
+
+// SetUpgradeProposals stores the upgrade proposals
+func SetUpgradeProposals(db KVStore, proposals []UpgradeProposal) error {
+	value, err := json.Marshal(proposals)
+	if err != nil {
+		return err
+	}
+	return db.Set(proposalsKey, value)
+}
+
+// ScheduledUpgrade returns the proposal whose plan is scheduled, nil if none is
+func ScheduledUpgrade(proposals []UpgradeProposal) *UpgradeProposal {
+	for i := range proposals {
+		if proposals[i].Status == ProposalScheduled {
+			return &proposals[i]
+		}
+	}
+	return nil
+}
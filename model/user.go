@@ -1,6 +1,9 @@
 package model
 
 import (
+	"fmt"
+	"regexp"
+
 	"github.com/cometbft/cometbft/crypto/ed25519"
 )
 
@@ -8,8 +11,86 @@ type User struct {
 	Name          string
 	PubKey        ed25519.PubKey `badgerhold:"index"` // this is just a wrapper around bytes
 	Moderator     bool
+	Admin         bool
 	Banned        bool
 	NumMessages   int64
 	Version       uint64
 	SchemaVersion int
+	// Activity of the user, maintained while blocks are executed
+	FirstPostHeight int64
+	LastPostHeight  int64
+	Strikes         int64
+	Edits           int64
+	Deletions       int64
+	// Nonce is the nonce expected on the next transaction of the user
+	Nonce uint64
+	// RecoveryKey can reset the key of the user if it is lost; it is optional
+	RecoveryKey ed25519.PubKey
+	// KeyHistory lists the keys the user had before the current one, oldest first
+	KeyHistory []KeyChange
+	// PendingRecovery is a key reset waiting for its delay to pass
+	PendingRecovery *Recovery
+	// Appeal is the request of a banned user to be unbanned, until a
+	// moderator resolves it
+	Appeal *Appeal
+}
+
+// Reasons for a key change
+const (
+	KeyChangeRotation = "rotation"
+	KeyChangeRecovery = "recovery"
+)
+
+// KeyChange records a key of the user that was replaced
+type KeyChange struct {
+	PubKey ed25519.PubKey `json:"pub_key"`
+	Height int64          `json:"height"`
+	Reason string         `json:"reason"`
+}
+
+// Recovery is a request to reset the key of a user. It is approved either by
+// the recovery key of the user or by moderators, and takes effect once enough
+// blocks passed since it was requested.
+type Recovery struct {
+	PubKey      ed25519.PubKey `json:"pub_key"`
+	Height      int64          `json:"height"`
+	RecoveryKey bool           `json:"recovery_key,omitempty"`
+	Moderators  []string       `json:"moderators,omitempty"`
+}
+
+// Appeal is the request of a banned user to be unbanned
+type Appeal struct {
+	Reason string `json:"reason"`
+	Height int64  `json:"height"`
+}
+
+// Approve records the approval of a moderator, once per moderator
+func (r *Recovery) Approve(moderator string) {
+	for _, m := range r.Moderators {
+		if m == moderator {
+			return
+		}
+	}
+	r.Moderators = append(r.Moderators, moderator)
+}
+
+// ReplaceKey sets a new key for the user and keeps the old one in its history
+func (u *User) ReplaceKey(pubKey ed25519.PubKey, height int64, reason string) {
+	u.KeyHistory = append(u.KeyHistory, KeyChange{PubKey: u.PubKey, Height: height, Reason: reason})
+	u.PubKey = pubKey
+}
+
+const MaxUserNameLength = 32
+
+var userNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// ValidateUserName checks that a name can be registered
+func ValidateUserName(name string) error {
+	if len(name) == 0 || len(name) > MaxUserNameLength {
+		return fmt.Errorf("user name must have between 1 and %d characters", MaxUserNameLength)
+	}
+	if !userNameRegexp.MatchString(name) {
+		return fmt.Errorf("user name %q may only contain letters, digits, '_', '-' and '.'", name)
+	}
+	return nil
 }
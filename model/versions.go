@@ -0,0 +1,278 @@
+package model
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// The state at past heights is kept as reverse deltas: when a block at height H
+// is written, the value every key it changes had before is stored under
+// versionKey(key, H). The value of a key at height h is then the one saved by
+// the first block after h that changed it, or the current value if no later
+// block did. Keys that never change cost nothing, and pruning the history
+// before a height only means deleting the deltas of the blocks up to it.
+//
+// The deltas are local to the node: they are not part of the app hash, and
+// each node chooses how much history it keeps.
+
+var (
+	// ErrReadOnly is returned when writing to the state at a past height
+	ErrReadOnly = errors.New("the state at a past height is read-only")
+	// ErrHeightPruned is returned for heights older than the history kept by the node
+	ErrHeightPruned = errors.New("the state at this height was pruned")
+)
+
+// Versions describes the history of the state kept in the DB
+type Versions struct {
+	// Earliest is the first height whose state can still be read, 0 if no
+	// history was recorded yet
+	Earliest int64 `json:"earliest"`
+	// Latest is the height of the last block written with its history
+	Latest int64 `json:"latest"`
+}
+
+// versionKey is the key of the value key had before the block at height.
+// The key is hex encoded so that the height after it can't be mistaken for a
+// part of the key, while keeping the order and prefixes of the keys.
+func versionKey(key []byte, height int64) []byte {
+	vk := versionPrefix(key)
+	return binary.BigEndian.AppendUint64(vk, uint64(height))
+}
+
+func versionPrefix(prefix []byte) []byte {
+	return []byte(PrefixVersion + hex.EncodeToString(prefix))
+}
+
+// parseVersionKey returns the key and height of a version key
+func parseVersionKey(vk []byte) ([]byte, int64, error) {
+	encoded := vk[len(PrefixVersion):]
+	if len(encoded) < 8 {
+		return nil, 0, fmt.Errorf("malformed version key %x", vk)
+	}
+	split := len(encoded) - 8
+	key, err := hex.DecodeString(string(encoded[:split]))
+	if err != nil {
+		return nil, 0, fmt.Errorf("malformed version key %x: %w", vk, err)
+	}
+	return key, int64(binary.BigEndian.Uint64(encoded[split:])), nil
+}
+
+// A saved value starts with a byte telling whether the key existed
+func encodeVersion(value []byte) []byte {
+	if value == nil {
+		return []byte{0}
+	}
+	return append([]byte{1}, value...)
+}
+
+func decodeVersion(v []byte) []byte {
+	if len(v) == 0 || v[0] == 0 {
+		return nil
+	}
+	return v[1:]
+}
+
+// GetVersions returns the history kept in the DB
+func GetVersions(db KVStore) (Versions, error) {
+	var versions Versions
+	value, err := db.Get(versionsKey)
+	if err != nil || value == nil {
+		return versions, err
+	}
+	err = json.Unmarshal(value, &versions)
+	return versions, err
+}
+
+func setVersions(store KVStore, versions Versions) error {
+	value, err := json.Marshal(versions)
+	if err != nil {
+		return err
+	}
+	return store.Set(versionsKey, value)
+}
+
+// WriteVersion persists the staged writes like Write, and keeps the values
+// they replace so that the state before the block at height can still be read
+// with AtHeight.
+func (c *Cache) WriteVersion(height int64) error {
+	versions, err := GetVersions(c.db)
+	if err != nil {
+		return err
+	}
+	for _, ch := range c.Changes() {
+		prev, err := c.db.Get(ch.Key)
+		if err != nil {
+			return err
+		}
+		vk := versionKey(ch.Key, height)
+		c.pending[string(vk)] = Change{Key: vk, Value: encodeVersion(prev)}
+	}
+	if versions.Earliest == 0 {
+		// The deltas of this block give the state before it
+		versions.Earliest = height - 1
+		if versions.Earliest < 1 {
+			versions.Earliest = 1
+		}
+	}
+	versions.Latest = height
+	if err := setVersions(c, versions); err != nil {
+		return err
+	}
+	return c.Write()
+}
+
+// AtHeight returns a read-only view of the state as it was after the block at
+// height was committed. height must not be above the last block written with
+// WriteVersion.
+func (db *DB) AtHeight(height int64) (KVStore, error) {
+	versions, err := GetVersions(db)
+	if err != nil {
+		return nil, err
+	}
+	if versions.Earliest == 0 || height < versions.Earliest {
+		return nil, fmt.Errorf("%w: the earliest height available is %d", ErrHeightPruned, versions.Earliest)
+	}
+	if height > versions.Latest {
+		return nil, fmt.Errorf("height %d is not committed yet", height)
+	}
+	return &heightView{db: db, height: height}, nil
+}
+
+type heightView struct {
+	db     *DB
+	height int64
+}
+
+var errStopIteration = errors.New("stop iteration")
+
+func (v *heightView) Get(key []byte) ([]byte, error) {
+	var (
+		value []byte
+		found bool
+	)
+	err := v.db.Iterate(versionPrefix(key), func(vk, saved []byte) error {
+		k, height, err := parseVersionKey(vk)
+		if err != nil {
+			return err
+		}
+		if string(k) != string(key) || height <= v.height {
+			return nil
+		}
+		value, found = decodeVersion(saved), true
+		return errStopIteration
+	})
+	if err != nil && !errors.Is(err, errStopIteration) {
+		return nil, err
+	}
+	if found {
+		return value, nil
+	}
+	return v.db.Get(key)
+}
+
+func (v *heightView) Set(_, _ []byte) error {
+	return ErrReadOnly
+}
+
+func (v *heightView) Delete(_ []byte) error {
+	return ErrReadOnly
+}
+
+// Iterate merges the current values with the ones saved by the blocks after
+// the height of the view
+func (v *heightView) Iterate(prefix []byte, fn func(key, value []byte) error) error {
+	merged := make(map[string][]byte)
+	err := v.db.Iterate(prefix, func(key, value []byte) error {
+		merged[string(key)] = value
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	restored := make(map[string]bool)
+	err = v.db.Iterate(versionPrefix(prefix), func(vk, saved []byte) error {
+		key, height, err := parseVersionKey(vk)
+		if err != nil {
+			return err
+		}
+		// The deltas of a key are in height order, the first one after the
+		// height of the view is the value it had then
+		if height <= v.height || restored[string(key)] {
+			return nil
+		}
+		restored[string(key)] = true
+		if value := decodeVersion(saved); value != nil {
+			merged[string(key)] = value
+		} else {
+			delete(merged, string(key))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(merged))
+	for k := range merged {
+		if !strings.HasPrefix(k, PrefixVersion) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := fn([]byte(k), merged[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PruneVersions deletes the history needed to read the state before height,
+// which becomes the earliest height available. It returns the number of
+// deltas deleted.
+func (db *DB) PruneVersions(height int64) (int, error) {
+	versions, err := GetVersions(db)
+	if err != nil {
+		return 0, err
+	}
+	if versions.Earliest == 0 || height <= versions.Earliest {
+		return 0, nil
+	}
+	if height > versions.Latest {
+		height = versions.Latest
+	}
+	// The state at height only needs the deltas of the blocks after it
+	var stale []Change
+	err = db.Iterate([]byte(PrefixVersion), func(vk, _ []byte) error {
+		_, h, err := parseVersionKey(vk)
+		if err != nil {
+			return err
+		}
+		if h <= height {
+			stale = append(stale, Change{Key: vk, Delete: true})
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	versions.Earliest = height
+	value, err := json.Marshal(versions)
+	if err != nil {
+		return 0, err
+	}
+	// Record the new earliest height first, so a crash half way through
+	// doesn't leave a height that looks readable without its deltas
+	if err := db.Set(versionsKey, value); err != nil {
+		return 0, err
+	}
+	if err := db.applyChanges(stale); err != nil {
+		return 0, err
+	}
+	db.logger.Debug("pruned history", "changes", len(stale), "earliest_height", height)
+	return len(stale), nil
+}
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MaxWordLength is the longest word the word list can hold
+const MaxWordLength = 64
+
+// The word list holds the curse words of the chain. Unlike the words each
+// validator configures in its app.toml and shares through vote extensions,
+// it is part of the state: it is set in the genesis and changed by admins.
+
+// ValidateWord checks that a word can be added to the word list
+func ValidateWord(word string) error {
+	if word == "" || len(word) > MaxWordLength {
+		return fmt.Errorf("words must have between 1 and %d characters", MaxWordLength)
+	}
+	if strings.Contains(word, "|") {
+		return errors.New("words can't contain '|'")
+	}
+	return nil
+}
+
+// GetWordList returns the word list, sorted
+func GetWordList(db KVStore) ([]string, error) {
+	value, err := db.Get(wordListKey)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	var words []string
+	err = json.Unmarshal(value, &words)
+	return words, err
+}
+
+// SetWordList replaces the word list
+func SetWordList(db KVStore, words []string) error {
+	words = append([]string(nil), words...)
+	sort.Strings(words)
+	value, err := json.Marshal(words)
+	if err != nil {
+		return err
+	}
+	return db.Set(wordListKey, value)
+}
+
+// UpdateWordList adds and removes words from the word list
+func UpdateWordList(db KVStore, add []string, remove []string) error {
+	words, err := GetWordList(db)
+	if err != nil {
+		return err
+	}
+	set := make(map[string]struct{}, len(words)+len(add))
+	for _, w := range words {
+		set[w] = struct{}{}
+	}
+	for _, w := range add {
+		set[w] = struct{}{}
+	}
+	for _, w := range remove {
+		delete(set, w)
+	}
+	updated := make([]string, 0, len(set))
+	for w := range set {
+		updated = append(updated, w)
+	}
+	return SetWordList(db, updated)
+}
@@ -0,0 +1,145 @@
+// Package node creates the home directories the forum node runs from.
+package node
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	cfg "github.com/cometbft/cometbft/config"
+	cmtos "github.com/cometbft/cometbft/libs/os"
+	"github.com/cometbft/cometbft/p2p"
+	"github.com/cometbft/cometbft/privval"
+	"github.com/cometbft/cometbft/types"
+	cmttime "github.com/cometbft/cometbft/types/time"
+	"github.com/spf13/viper"
+
+	forum "github.com/alijnmerchant21/forum-updated/abci"
+)
+
+// DefaultHome is the home directory of the node when none is given: $FORUM_HOME,
+// or $HOME/.forum
+var DefaultHome = defaultHome()
+
+func defaultHome() string {
+	if home := os.Getenv(forum.EnvPrefix + "_HOME"); home != "" {
+		return home
+	}
+	return os.ExpandEnv("$HOME/.forum")
+}
+
+// validatorPower is the voting power of the validators in the generated genesis files
+const validatorPower = 10
+
+// AppConfigFile returns the path of the application configuration in a home directory
+func AppConfigFile(home string) string {
+	return filepath.Join(home, "config", "app.toml")
+}
+
+// LoadAppConfig reads the application configuration of a home directory,
+// with the paths in it relative to the home directory
+func LoadAppConfig(home string) (*forum.Config, error) {
+	appConfig, err := forum.LoadConfig(AppConfigFile(home))
+	if err != nil {
+		return nil, err
+	}
+	return appConfig.SetRoot(home), nil
+}
+
+// LoadConfig reads the CometBFT configuration of a home directory
+func LoadConfig(home string) (*cfg.Config, error) {
+	config := cfg.DefaultConfig()
+	file := filepath.Join(home, "config", "config.toml")
+	v := viper.New()
+	v.SetConfigFile(file)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read config %q: %w", file, err)
+	}
+	if err := v.Unmarshal(config); err != nil {
+		return nil, fmt.Errorf("failed to load config from %q: %w", file, err)
+	}
+	config.SetRoot(home)
+	if err := config.ValidateBasic(); err != nil {
+		return nil, fmt.Errorf("invalid config %q: %w", file, err)
+	}
+	return config, nil
+}
+
+// InitFiles creates the home directory of a single validator node: the
+// CometBFT configuration, the validator and node keys, a genesis file with
+// this validator and an empty app_state, and app.toml. Files that already
+// exist are kept, so running it twice is harmless.
+func InitFiles(home string, chainID string) error {
+	config := cfg.DefaultConfig()
+	config.SetRoot(home)
+	cfg.EnsureRoot(home)
+
+	pv, err := loadOrGenFilePV(config)
+	if err != nil {
+		return err
+	}
+	if _, err := p2p.LoadOrGenNodeKey(config.NodeKeyFile()); err != nil {
+		return err
+	}
+	if !cmtos.FileExists(config.GenesisFile()) {
+		validator, err := genesisValidator(pv, "")
+		if err != nil {
+			return err
+		}
+		genDoc := newGenesisDoc(chainID, []types.GenesisValidator{validator})
+		if err := genDoc.SaveAs(config.GenesisFile()); err != nil {
+			return err
+		}
+	}
+
+	appConfig := forum.DefaultConfig()
+	appConfig.ChainID = chainID
+	return writeAppConfig(home, appConfig)
+}
+
+func loadOrGenFilePV(config *cfg.Config) (*privval.FilePV, error) {
+	keyFile := config.PrivValidatorKeyFile()
+	stateFile := config.PrivValidatorStateFile()
+	if cmtos.FileExists(keyFile) {
+		return privval.LoadFilePV(keyFile, stateFile), nil
+	}
+	if err := os.MkdirAll(filepath.Dir(stateFile), 0o700); err != nil {
+		return nil, err
+	}
+	pv := privval.GenFilePV(keyFile, stateFile)
+	pv.Save()
+	return pv, nil
+}
+
+func genesisValidator(pv *privval.FilePV, name string) (types.GenesisValidator, error) {
+	pubKey, err := pv.GetPubKey()
+	if err != nil {
+		return types.GenesisValidator{}, fmt.Errorf("can't get pubkey: %w", err)
+	}
+	return types.GenesisValidator{
+		Address: pubKey.Address(),
+		PubKey:  pubKey,
+		Power:   validatorPower,
+		Name:    name,
+	}, nil
+}
+
+// newGenesisDoc returns a genesis with an empty forum; the application
+// enables vote extensions itself in InitChain
+func newGenesisDoc(chainID string, validators []types.GenesisValidator) *types.GenesisDoc {
+	return &types.GenesisDoc{
+		ChainID:         chainID,
+		GenesisTime:     cmttime.Now(),
+		ConsensusParams: types.DefaultConsensusParams(),
+		Validators:      validators,
+		AppState:        []byte("{}"),
+	}
+}
+
+func writeAppConfig(home string, appConfig *forum.Config) error {
+	file := AppConfigFile(home)
+	if cmtos.FileExists(file) {
+		return nil
+	}
+	return forum.WriteConfigFile(file, appConfig)
+}
@@ -0,0 +1,56 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+
+	cfg "github.com/cometbft/cometbft/config"
+	cmtlog "github.com/cometbft/cometbft/libs/log"
+	nm "github.com/cometbft/cometbft/node"
+	"github.com/cometbft/cometbft/p2p"
+	"github.com/cometbft/cometbft/privval"
+	"github.com/cometbft/cometbft/proxy"
+
+	forum "github.com/alijnmerchant21/forum-updated/abci"
+)
+
+// ValidateConfig checks that the CometBFT configuration of a node supports
+// its application configuration. The per-sender limit of the mempool is
+// counted again from the transactions CometBFT rechecks after each block,
+// so it needs rechecking.
+func ValidateConfig(config *cfg.Config, appConfig *forum.Config) error {
+	if appConfig.Mempool.MaxTxsPerSender > 0 && !config.Mempool.Recheck {
+		return errors.New("mempool.max_txs_per_sender of app.toml needs mempool.recheck = true in config.toml")
+	}
+	return nil
+}
+
+// New creates a CometBFT node running the application in the same process,
+// with the keys and genesis of the home directory of the config. The node is
+// not started.
+func New(config *cfg.Config, app *forum.ForumApp, logger cmtlog.Logger) (*nm.Node, error) {
+	nodeKey, err := p2p.LoadNodeKey(config.NodeKeyFile())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load node key: %w", err)
+	}
+
+	pv := privval.LoadFilePV(
+		config.PrivValidatorKeyFile(),
+		config.PrivValidatorStateFile(),
+	)
+
+	n, err := nm.NewNode(
+		config,
+		pv,
+		nodeKey,
+		proxy.NewLocalClientCreator(app),
+		nm.DefaultGenesisDocProviderFunc(config),
+		cfg.DefaultDBProvider,
+		nm.DefaultMetricsProvider(config.Instrumentation),
+		logger,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CometBFT node: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,93 @@
+package node
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	cfg "github.com/cometbft/cometbft/config"
+	"github.com/cometbft/cometbft/p2p"
+	"github.com/cometbft/cometbft/types"
+
+	forum "github.com/alijnmerchant21/forum-updated/abci"
+)
+
+// Ports of the first node of a testnet. Node i uses them plus portOffset*i,
+// so all the nodes can run on this machine.
+const (
+	basePortP2P   = 26656
+	basePortRPC   = 26657
+	basePortProxy = 26658
+	basePortHTTP  = 8080
+	portOffset    = 10
+)
+
+// InitTestnet creates the home directories of a local testnet of validators
+// in dir/node0, dir/node1, ... They share a genesis file listing all the
+// validators, and each node has the others as persistent peers.
+func InitTestnet(dir string, validators int, chainID string) error {
+	if validators < 1 {
+		return fmt.Errorf("a testnet needs at least one validator, got %d", validators)
+	}
+
+	configs := make([]*cfg.Config, validators)
+	genValidators := make([]types.GenesisValidator, validators)
+	peers := make([]string, validators)
+	for i := range configs {
+		home := NodeHome(dir, i)
+		config := cfg.DefaultConfig()
+		config.SetRoot(home)
+		cfg.EnsureRoot(home)
+		config.Moniker = nodeName(i)
+		config.P2P.ListenAddress = localAddr(basePortP2P, i)
+		config.RPC.ListenAddress = localAddr(basePortRPC, i)
+		config.ProxyApp = localAddr(basePortProxy, i)
+		// All the nodes share one IP address
+		config.P2P.AllowDuplicateIP = true
+		config.P2P.AddrBookStrict = false
+		configs[i] = config
+
+		pv, err := loadOrGenFilePV(config)
+		if err != nil {
+			return err
+		}
+		if genValidators[i], err = genesisValidator(pv, config.Moniker); err != nil {
+			return err
+		}
+		nodeKey, err := p2p.LoadOrGenNodeKey(config.NodeKeyFile())
+		if err != nil {
+			return err
+		}
+		peers[i] = fmt.Sprintf("%s@127.0.0.1:%d", nodeKey.ID(), basePortP2P+portOffset*i)
+	}
+
+	genDoc := newGenesisDoc(chainID, genValidators)
+	for i, config := range configs {
+		if err := genDoc.SaveAs(config.GenesisFile()); err != nil {
+			return err
+		}
+		config.P2P.PersistentPeers = strings.Join(append(append([]string{}, peers[:i]...), peers[i+1:]...), ",")
+		cfg.WriteConfigFile(filepath.Join(config.RootDir, "config", "config.toml"), config)
+
+		appConfig := forum.DefaultConfig()
+		appConfig.ChainID = chainID
+		appConfig.HTTPAddress = fmt.Sprintf("127.0.0.1:%d", basePortHTTP+i)
+		if err := writeAppConfig(config.RootDir, appConfig); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NodeHome returns the home directory of node i of a testnet
+func NodeHome(dir string, i int) string {
+	return filepath.Join(dir, nodeName(i))
+}
+
+func nodeName(i int) string {
+	return fmt.Sprintf("node%d", i)
+}
+
+func localAddr(port int, i int) string {
+	return fmt.Sprintf("tcp://127.0.0.1:%d", port+portOffset*i)
+}
@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/alijnmerchant21/forum-updated/model"
+)
+
+// Params limit how many posts a user can make within a window of blocks.
+// They are part of the consensus state; a zero value disables the limit.
+type Params struct {
+	// MaxPosts is the number of posts allowed within the window
+	MaxPosts uint64 `json:"max_posts"`
+	// WindowBlocks is the length of the window, in blocks
+	WindowBlocks int64 `json:"window_blocks"`
+}
+
+func (p Params) Enabled() bool {
+	return p.MaxPosts > 0 && p.WindowBlocks > 0
+}
+
+func (p Params) Validate() error {
+	if p.WindowBlocks < 0 {
+		return errors.New("rate limit window_blocks can't be negative")
+	}
+	if (p.MaxPosts > 0) != (p.WindowBlocks > 0) {
+		return errors.New("rate limit needs both max_posts and window_blocks")
+	}
+	return nil
+}
+
+// RecentPosts returns the heights of the posts of the user that still count
+// against the limit at the given height, i.e. the ones made in the last
+// WindowBlocks blocks including the given one.
+func RecentPosts(store model.KVStore, user string, height int64, p Params) ([]int64, error) {
+	postsBytes, err := store.Get(model.RateLimitKey(user))
+	if err != nil || postsBytes == nil {
+		return nil, err
+	}
+	var heights []int64
+	if err := json.Unmarshal(postsBytes, &heights); err != nil {
+		return nil, err
+	}
+	recent := heights[:0]
+	for _, h := range heights {
+		if h > height-p.WindowBlocks {
+			recent = append(recent, h)
+		}
+	}
+	return recent, nil
+}
+
+// Remaining returns how many more posts the user can make at the given height
+func Remaining(store model.KVStore, user string, height int64, p Params) (uint64, error) {
+	if !p.Enabled() {
+		return ^uint64(0), nil
+	}
+	recent, err := RecentPosts(store, user, height, p)
+	if err != nil {
+		return 0, err
+	}
+	if uint64(len(recent)) >= p.MaxPosts {
+		return 0, nil
+	}
+	return p.MaxPosts - uint64(len(recent)), nil
+}
+
+// RecordPost counts a post of the user at the given height. Posts that fell
+// out of the window are forgotten.
+func RecordPost(store model.KVStore, user string, height int64, p Params) error {
+	if !p.Enabled() {
+		return nil
+	}
+	recent, err := RecentPosts(store, user, height, p)
+	if err != nil {
+		return err
+	}
+	postsBytes, err := json.Marshal(append(recent, height))
+	if err != nil {
+		return err
+	}
+	return store.Set(model.RateLimitKey(user), postsBytes)
+}
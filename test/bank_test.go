@@ -0,0 +1,117 @@
+package test
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"testing"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/cometbft/cometbft/types"
+	"github.com/stretchr/testify/require"
+
+	forum "github.com/alijnmerchant21/forum-updated/abci"
+	"github.com/alijnmerchant21/forum-updated/model"
+)
+
+func queryBalance(t *testing.T, app *forum.ForumApp, user string) string {
+	resp, err := app.Query(context.Background(), &abci.RequestQuery{Path: forum.QueryPathBalance, Data: []byte(user)})
+	require.NoError(t, err)
+	return string(resp.Value)
+}
+
+func TestPostFeesAndTransfers(t *testing.T) {
+	app := newApp(t)
+	ctx := context.Background()
+	alice, bob := newAccount("alice"), newAccount("bob")
+	initChain(t, app, `{
+		"params": {"fees": {"post_fee": 10, "fee_per_byte": 1}},
+		`+genesisUsers(alice, bob)+`,
+		"balances": [{"user": "alice", "amount": 100}]
+	}`)
+
+	// bob has no tokens and can't post
+	resp, err := app.CheckTx(ctx, &abci.RequestCheckTx{Tx: bob.post(t, "hello")})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeInsufficientFunds, resp.Code)
+	bob.nonce--
+
+	post := alice.post(t, "hello") // costs 15
+	transfer := alice.tx(t, model.TxTypeTransfer, model.TransferTx{To: "bob", Amount: 20})
+	resp, err = app.CheckTx(ctx, &abci.RequestCheckTx{Tx: transfer})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeOK, resp.Code)
+
+	txs := [][]byte{
+		post,
+		transfer,
+		bob.post(t, "hi"),          // costs 12
+		bob.post(t, "hello again"), // costs 21, bob has 8 left
+	}
+	res, err := app.FinalizeBlock(ctx, &abci.RequestFinalizeBlock{Txs: txs, Height: 1})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeOK, res.TxResults[0].Code)
+	require.Equal(t, forum.CodeTypeOK, res.TxResults[1].Code)
+	require.Equal(t, forum.EventTypeTransfer, res.TxResults[1].Events[0].Type)
+	require.Equal(t, forum.CodeTypeOK, res.TxResults[2].Code)
+	require.Equal(t, forum.CodeTypeInsufficientFunds, res.TxResults[3].Code)
+	_, err = app.Commit(ctx, &abci.RequestCommit{})
+	require.NoError(t, err)
+
+	require.Equal(t, "65", queryBalance(t, app, "alice"))
+	require.Equal(t, "8", queryBalance(t, app, "bob"))
+
+	// The rejected post was not stored
+	query, err := app.Query(ctx, &abci.RequestQuery{Data: []byte("bob")})
+	require.NoError(t, err)
+	require.NotContains(t, string(query.Value), "hello again")
+}
+
+func TestFailedTransferKeepsFunds(t *testing.T) {
+	app := newApp(t)
+	alice, bob := newAccount("alice"), newAccount("bob")
+	initChain(t, app, `{
+		`+genesisUsers(alice, bob)+`,
+		"balances": [{"user": "alice", "amount": 100}, {"user": "bob", "amount": `+strconv.FormatUint(math.MaxUint64-5, 10)+`}]
+	}`)
+
+	res := finalizeAndCommit(t, app, 1, alice.tx(t, model.TxTypeTransfer, model.TransferTx{To: "bob", Amount: 10}))
+	require.NotEqual(t, forum.CodeTypeOK, res[0].Code)
+	require.Equal(t, "100", queryBalance(t, app, "alice"))
+	require.Equal(t, strconv.FormatUint(math.MaxUint64-5, 10), queryBalance(t, app, "bob"))
+}
+
+func TestFeePriorityKeepsNonceOrder(t *testing.T) {
+	app := newApp(t, withConfig(`
+curse_words = "bad"
+
+[mempool]
+priority_policy = "fee"
+`))
+	alice, bob := newAccount("alice"), newAccount("bob")
+	initChain(t, app, `{
+		"params": {"fees": {"post_fee": 1, "fee_per_byte": 1}},
+		`+genesisUsers(alice, bob)+`,
+		"balances": [{"user": "alice", "amount": 100}, {"user": "bob", "amount": 100}]
+	}`)
+
+	// alice's second post pays more than bob's, her first one less
+	first, second := alice.post(t, "a"), alice.post(t, "a much longer message")
+	post := bob.post(t, "a longer one")
+	resp, err := app.PrepareProposal(context.Background(), &abci.RequestPrepareProposal{Txs: [][]byte{post, first, second}, MaxTxBytes: 1 << 20})
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{first, second, post}, resp.Txs)
+	for _, r := range finalizeAndCommit(t, app, 1, resp.Txs...) {
+		require.Equal(t, forum.CodeTypeOK, r.Code, r.Log)
+	}
+}
+
+func TestInitChainRejectsInvalidGenesis(t *testing.T) {
+	app := newApp(t)
+	params := types.DefaultConsensusParams().ToProto()
+	_, err := app.InitChain(context.Background(), &abci.RequestInitChain{
+		ConsensusParams: &params,
+		AppStateBytes:   []byte(`{"balances": [{"user": "alice", "amount": 1}, {"user": "alice", "amount": 2}]}`),
+	})
+	require.Error(t, err)
+}
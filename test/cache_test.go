@@ -0,0 +1,93 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alijnmerchant21/forum-updated/model"
+)
+
+func TestCacheReadsThroughPendingWrites(t *testing.T) {
+	db := newInMemoryDB(t)
+	require.NoError(t, model.AddMessage(db, model.Message{Sender: "alice", Message: "hello"}))
+
+	// A second message from the same sender in the same block must see the first one
+	cache := db.NewCache()
+	require.NoError(t, model.AddMessage(cache, model.Message{Sender: "alice", Message: "world"}))
+	require.NoError(t, model.AddMessage(cache, model.Message{Sender: "alice", Message: "again"}))
+	messages, err := model.GetMessagesBySender(cache, "alice")
+	require.NoError(t, err)
+	require.Len(t, messages, 3)
+	require.Equal(t, uint64(3), messages[2].ID)
+
+	// The database is untouched until the cache is written
+	committed, err := model.GetMessagesBySender(db, "alice")
+	require.NoError(t, err)
+	require.Len(t, committed, 1)
+
+	require.NoError(t, cache.Delete([]byte("history")))
+	value, err := cache.Get([]byte("history"))
+	require.NoError(t, err)
+	require.Nil(t, value)
+
+	require.NoError(t, cache.Write())
+	committed, err = model.GetMessagesBySender(db, "alice")
+	require.NoError(t, err)
+	require.Len(t, committed, 3)
+	value, err = db.Get([]byte("history"))
+	require.NoError(t, err)
+	require.Nil(t, value)
+}
+
+func TestCacheIterate(t *testing.T) {
+	db := newInMemoryDB(t)
+	require.NoError(t, db.Set([]byte("val1"), []byte("a")))
+	require.NoError(t, db.Set([]byte("val2"), []byte("b")))
+
+	cache := db.NewCache()
+	require.NoError(t, cache.Delete([]byte("val1")))
+	require.NoError(t, cache.Set([]byte("val3"), []byte("c")))
+	require.NoError(t, cache.Set([]byte("other"), []byte("d")))
+
+	var keys []string
+	err := cache.Iterate([]byte("val"), func(key, value []byte) error {
+		keys = append(keys, string(key))
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"val2", "val3"}, keys)
+}
+
+func TestRecoverJournal(t *testing.T) {
+	db := newInMemoryDB(t)
+
+	// Nothing to recover on a clean database
+	recovered, err := db.RecoverJournal()
+	require.NoError(t, err)
+	require.False(t, recovered)
+
+	// Simulate a crash after the journal was written but before the batch was applied
+	require.NoError(t, db.Set([]byte("stale"), []byte("x")))
+	journal, err := json.Marshal([]model.Change{
+		{Key: []byte("appstate"), Value: []byte(`{"size":1,"height":5}`)},
+		{Key: []byte("stale"), Delete: true},
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Set([]byte("journal"), journal))
+
+	recovered, err = db.RecoverJournal()
+	require.NoError(t, err)
+	require.True(t, recovered)
+
+	value, err := db.Get([]byte("appstate"))
+	require.NoError(t, err)
+	require.Equal(t, `{"size":1,"height":5}`, string(value))
+	value, err = db.Get([]byte("stale"))
+	require.NoError(t, err)
+	require.Nil(t, value)
+	value, err = db.Get([]byte("journal"))
+	require.NoError(t, err)
+	require.Nil(t, value)
+}
@@ -0,0 +1,108 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	cmttypes "github.com/cometbft/cometbft/types"
+	"github.com/stretchr/testify/require"
+
+	forum "github.com/alijnmerchant21/forum-updated/abci"
+	"github.com/alijnmerchant21/forum-updated/model"
+)
+
+func TestCheckTxSenderLimit(t *testing.T) {
+	app := newApp(t, withConfig(`
+curse_words = "bad"
+
+[mempool]
+max_txs_per_sender = 2
+`))
+	ctx := context.Background()
+	alice, bob := newAccount("alice"), newAccount("bob")
+	initChain(t, app, `{`+genesisUsers(alice, bob)+`}`)
+
+	one, two, three := alice.post(t, "one"), alice.post(t, "two"), alice.post(t, "three")
+	for _, tx := range [][]byte{one, two} {
+		resp, err := app.CheckTx(ctx, &abci.RequestCheckTx{Tx: tx})
+		require.NoError(t, err)
+		require.Equal(t, forum.CodeTypeOK, resp.Code)
+	}
+	resp, err := app.CheckTx(ctx, &abci.RequestCheckTx{Tx: three})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeSenderLimit, resp.Code)
+
+	// Other senders are not affected
+	resp, err = app.CheckTx(ctx, &abci.RequestCheckTx{Tx: bob.post(t, "one")})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeOK, resp.Code)
+
+	// After a block the counts are rebuilt from the rechecked transactions
+	finalizeAndCommit(t, app, 1, one)
+	resp, err = app.CheckTx(ctx, &abci.RequestCheckTx{Tx: two, Type: abci.CheckTxType_Recheck})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeOK, resp.Code)
+	resp, err = app.CheckTx(ctx, &abci.RequestCheckTx{Tx: three})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeOK, resp.Code)
+}
+
+func TestPrioritizeKeepsNonceOrder(t *testing.T) {
+	app := newApp(t, withConfig(`
+curse_words = "bad"
+
+[mempool]
+priority_policy = "moderator"
+`))
+	alice, mod := newAccount("alice"), newAccount("mod")
+	initChain(t, app, `{`+genesisUsers(alice, mod)+`, "moderators": ["mod"]}`)
+
+	// The mempool accepts nonces ahead of the account, so it can hold them out of order
+	first, second := mod.post(t, "first"), mod.post(t, "second")
+	post := alice.post(t, "hello")
+	resp, err := app.PrepareProposal(context.Background(), &abci.RequestPrepareProposal{Txs: [][]byte{post, second, first}, MaxTxBytes: 1 << 20})
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{first, second, post}, resp.Txs)
+	for _, r := range finalizeAndCommit(t, app, 1, resp.Txs...) {
+		require.Equal(t, forum.CodeTypeOK, r.Code, r.Log)
+	}
+}
+
+func TestCheckTxRecheckEvictsBannedUsers(t *testing.T) {
+	app := newApp(t)
+	ctx := context.Background()
+	mallory := newAccount("mallory")
+	initChain(t, app, `{`+genesisUsers(mallory)+`}`)
+
+	tx := mallory.post(t, "hello")
+	resp, err := app.CheckTx(ctx, &abci.RequestCheckTx{Tx: tx})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeOK, resp.Code)
+
+	banTx, err := json.Marshal(model.BanTx{UserName: "mallory", Reason: forum.BanReasonCurseWord})
+	require.NoError(t, err)
+
+	// Ban transactions are never accepted from the mempool
+	resp, err = app.CheckTx(ctx, &abci.RequestCheckTx{Tx: banTx})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeInvalidTxFormat, resp.Code)
+
+	finalizeAndCommit(t, app, 1, banTx)
+
+	resp, err = app.CheckTx(ctx, &abci.RequestCheckTx{Tx: tx, Type: abci.CheckTxType_Recheck})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeBanned, resp.Code)
+}
+
+func TestPrepareProposalRespectsMaxTxBytes(t *testing.T) {
+	app := newApp(t)
+	alice, bob := newAccount("alice"), newAccount("bob")
+	initChain(t, app, `{`+genesisUsers(alice, bob)+`}`)
+	txs := [][]byte{alice.post(t, "hello"), bob.post(t, "hello")}
+	maxTxBytes := cmttypes.ComputeProtoSizeForTxs([]cmttypes.Tx{txs[0]}) + 1
+	resp, err := app.PrepareProposal(context.Background(), &abci.RequestPrepareProposal{Txs: txs, MaxTxBytes: maxTxBytes})
+	require.NoError(t, err)
+	require.Equal(t, txs[:1], resp.Txs)
+}
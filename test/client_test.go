@@ -0,0 +1,127 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	cfg "github.com/cometbft/cometbft/config"
+	cmtlog "github.com/cometbft/cometbft/libs/log"
+	"github.com/cometbft/cometbft/types"
+	"github.com/stretchr/testify/require"
+
+	forum "github.com/alijnmerchant21/forum-updated/abci"
+	"github.com/alijnmerchant21/forum-updated/client"
+	"github.com/alijnmerchant21/forum-updated/keyring"
+	"github.com/alijnmerchant21/forum-updated/model"
+	"github.com/alijnmerchant21/forum-updated/node"
+)
+
+// startTestNode runs a single validator node in this process, with the
+// app_state in its genesis, and returns a client of it signing with the key
+func startTestNode(t *testing.T, appState string) func(key *keyring.Key) *client.Client {
+	home := t.TempDir()
+	require.NoError(t, node.InitFiles(home, "forum-test"))
+	config, err := node.LoadConfig(home)
+	require.NoError(t, err)
+	config.Consensus = cfg.TestConsensusConfig()
+	config.SetRoot(home)
+	config.P2P.ListenAddress = "tcp://127.0.0.1:0"
+	config.RPC.ListenAddress = ""
+
+	genDoc, err := types.GenesisDocFromFile(config.GenesisFile())
+	require.NoError(t, err)
+	genDoc.AppState = []byte(appState)
+	require.NoError(t, genDoc.SaveAs(config.GenesisFile()))
+
+	appConfig, err := node.LoadAppConfig(home)
+	require.NoError(t, err)
+	app, err := forum.NewForumApp(appConfig, cmtlog.NewNopLogger())
+	require.NoError(t, err)
+	n, err := node.New(config, app, cmtlog.NewNopLogger())
+	require.NoError(t, err)
+	require.NoError(t, n.Start())
+	t.Cleanup(func() {
+		require.NoError(t, n.Stop())
+		n.Wait()
+		app.Close()
+	})
+
+	return func(key *keyring.Key) *client.Client {
+		c := client.NewLocal(n, key)
+		c.SetBroadcastMode(client.BroadcastCommit)
+		return c
+	}
+}
+
+func testKey(t *testing.T, kr *keyring.Keyring, name string) *keyring.Key {
+	key, err := kr.Generate(name)
+	require.NoError(t, err)
+	return key
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	kr, err := keyring.New(t.TempDir())
+	require.NoError(t, err)
+	aliceKey, modKey := testKey(t, kr, "alice"), testKey(t, kr, "mod")
+	newClient := startTestNode(t, `{
+		"users": [{"name": "mod", "pub_key": "`+encodeKey(modKey.PrivKey)+`"}],
+		"moderators": ["mod"]
+	}`)
+	alice, mod := newClient(aliceKey), newClient(modKey)
+
+	_, err = alice.Register(ctx, nil)
+	require.NoError(t, err)
+	res, err := alice.Post(ctx, "hello", "")
+	require.NoError(t, err)
+	require.Positive(t, res.Height)
+	require.Equal(t, forum.EventTypePost, res.Events[0].Type)
+	_, err = alice.Reply(ctx, 1, "hello again")
+	require.NoError(t, err)
+	_, err = mod.Flag(ctx, 2, "repetitive")
+	require.NoError(t, err)
+
+	u, err := alice.GetUser(ctx, "alice")
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), u.Nonce)
+	messages, err := alice.GetMessages(ctx, "alice")
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	require.Equal(t, uint64(1), messages[1].ReplyTo)
+	page, err := alice.History(ctx, 2, 1)
+	require.NoError(t, err)
+	require.Equal(t, []model.HistoryEntry{{Sender: "alice", Message: "hello again"}}, page.Entries)
+	flags, err := alice.Flags(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, "mod", flags[0].User)
+
+	// Rejected transactions come back as a TxError, and don't use up the nonce
+	_, err = alice.Ban(ctx, "mod", "")
+	var txErr *client.TxError
+	require.True(t, errors.As(err, &txErr))
+	require.Equal(t, forum.CodeTypeUnauthorized, txErr.Code)
+
+	_, err = mod.Ban(ctx, "alice", "spam")
+	require.NoError(t, err)
+	bans, err := mod.Bans(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"alice"}, bans)
+	_, err = alice.Appeal(ctx, "it won't happen again")
+	require.NoError(t, err)
+	_, err = mod.ResolveAppeal(ctx, "alice", true)
+	require.NoError(t, err)
+	_, err = alice.Post(ctx, "back", "")
+	require.NoError(t, err)
+
+	moderators, err := alice.Moderators(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"mod"}, moderators)
+	params, err := alice.Params(ctx)
+	require.NoError(t, err)
+	require.Equal(t, forum.DefaultParams().Upgrade.VotingPeriod, params.Upgrade.VotingPeriod)
+
+	// Clients without a key only query
+	_, err = newClient(nil).Post(ctx, "anonymous", "")
+	require.ErrorIs(t, err, client.ErrNoKey)
+}
@@ -0,0 +1,89 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	cmtlog "github.com/cometbft/cometbft/libs/log"
+	"github.com/stretchr/testify/require"
+
+	forum "github.com/alijnmerchant21/forum-updated/abci"
+)
+
+func writeConfig(t *testing.T, config string) string {
+	file := filepath.Join(t.TempDir(), "app.toml")
+	require.NoError(t, os.WriteFile(file, []byte(config), 0o600))
+	return file
+}
+
+func TestConfigTemplate(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.toml")
+	want := forum.DefaultConfig()
+	require.NoError(t, forum.WriteConfigFile(file, want))
+	got, err := forum.LoadConfig(file)
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+}
+
+func TestConfigEnvOverrides(t *testing.T) {
+	file := writeConfig(t, `
+curse_words = "bad"
+db_backend = "goleveldb"
+
+[mempool]
+priority_policy = "fee"
+`)
+	t.Setenv("FORUM_CURSE_WORDS", "rain|cry")
+	t.Setenv("FORUM_MEMPOOL_MAX_TXS_PER_SENDER", "3")
+	t.Setenv("FORUM_MIGRATE_ON_STARTUP", "false")
+	t.Setenv("FORUM_LOG_FORMAT", "json")
+
+	cfg, err := forum.LoadConfig(file)
+	require.NoError(t, err)
+	require.Equal(t, "rain|cry", cfg.CurseWords)
+	require.Equal(t, "goleveldb", cfg.DBBackend)
+	require.Equal(t, forum.PriorityPolicyFee, cfg.Mempool.PriorityPolicy)
+	require.Equal(t, 3, cfg.Mempool.MaxTxsPerSender)
+	require.False(t, cfg.MigrateOnStartup)
+	require.Equal(t, forum.LogFormatJSON, cfg.LogFormat)
+
+	t.Setenv("FORUM_MEMPOOL_MAX_TXS_PER_SENDER", "many")
+	_, err = forum.LoadConfig(file)
+	require.ErrorContains(t, err, "FORUM_MEMPOOL_MAX_TXS_PER_SENDER")
+}
+
+func TestInvalidConfig(t *testing.T) {
+	for name, config := range map[string]string{
+		"unknown parameter": `curse_word = "bad"`,
+		"unknown section":   "[mempol]\npriority_policy = \"fee\"",
+		"wrong type":        `migrate_on_startup = "yes"`,
+		"empty word":        `curse_words = "bad||cry"`,
+		"log level":         `log_level = "loud"`,
+		"log format":        `log_format = "xml"`,
+		"priority policy":   "[mempool]\npriority_policy = \"random\"",
+		"empty db_dir":      `db_dir = ""`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := forum.LoadConfig(writeConfig(t, config))
+			require.Error(t, err)
+		})
+	}
+
+	_, err := forum.LoadConfig(filepath.Join(t.TempDir(), "app.toml"))
+	require.Error(t, err, "a missing config file is an error")
+}
+
+func TestConfigDBPath(t *testing.T) {
+	home := t.TempDir()
+	cfg := forum.DefaultConfig().SetRoot(home)
+	require.Equal(t, filepath.Join(home, "data", "forum-db"), cfg.DBPath())
+
+	app, err := forum.NewForumApp(cfg, cmtlog.NewNopLogger())
+	require.NoError(t, err)
+	app.Close()
+	require.DirExists(t, filepath.Join(home, "data", "forum-db"))
+
+	cfg.DBDir = filepath.Join(t.TempDir(), "state")
+	require.Equal(t, cfg.DBDir, cfg.DBPath())
+}
@@ -21,7 +21,7 @@ func TestCreateUser(t *testing.T) {
 
 	// Create a new DB instance for testing
 	testDB := &model.DB{}
-	testDB.Init(db)
+	testDB.Init(model.NewBadgerStore(db))
 
 	// Create a new user
 	user := &model.User{
@@ -35,8 +35,8 @@ func TestCreateUser(t *testing.T) {
 	require.NoError(t, err)
 
 	// Check that the user was saved to the database
-	err = testDB.GetDB().View(func(txn *badger.Txn) error {
-		item, err := txn.Get([]byte(user.Name))
+	err = db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(model.UserKey(user.Name))
 		if err != nil {
 			return err
 		}
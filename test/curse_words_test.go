@@ -0,0 +1,37 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	forum "github.com/alijnmerchant21/forum-updated/abci"
+)
+
+func TestIsCurseWord(t *testing.T) {
+	const curseWords = "bad|cry|dark magic"
+	for _, tt := range []struct {
+		text string
+		want bool
+	}{
+		// Words of the text, without case or punctuation
+		{"bad", true},
+		{"you are bad", true},
+		{"Bad!", true},
+		{"don't CRY.", true},
+		{"the dark magic of it", true},
+		// Short texts that are part of the list, or words that contain a curse word
+		{"a", false},
+		{"ad", false},
+		{"|", false},
+		{"bad|cry", true},
+		{"badge", false},
+		{"crystal", false},
+		{"dark", false},
+		{"magic dark", false},
+		{"", false},
+	} {
+		require.Equal(t, tt.want, forum.IsCurseWord(tt.text, curseWords), tt.text)
+	}
+	require.False(t, forum.IsCurseWord("bad", ""))
+}
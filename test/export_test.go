@@ -0,0 +1,97 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/stretchr/testify/require"
+
+	forum "github.com/alijnmerchant21/forum-updated/abci"
+	"github.com/alijnmerchant21/forum-updated/model"
+)
+
+func TestExportAndRestart(t *testing.T) {
+	app := newApp(t)
+	alice, bob, carol := newAccount("alice"), newAccount("bob"), newAccount("carol")
+	initChain(t, app, `{
+		"params": {"fees": {"post_fee": 1}},
+		`+genesisUsers(alice, bob, carol)+`,
+		"admins": ["alice"],
+		"moderators": ["bob"],
+		"word_list": ["troll"],
+		"boards": [{"name": "general"}, {"name": "dev"}],
+		"balances": [{"user": "alice", "amount": 100}, {"user": "bob", "amount": 100}]
+	}`)
+
+	banTx, err := json.Marshal(model.BanTx{UserName: "mallory", Reason: forum.BanReasonCurseWord})
+	require.NoError(t, err)
+	finalizeAndCommit(t, app, 1,
+		banTx,
+		alice.post(t, "hello"),
+		bob.tx(t, model.TxTypePost, model.PostTx{Message: "first", Board: "dev"}),
+		bob.post(t, "second"),
+		alice.tx(t, model.TxTypeProfile, model.Profile{DisplayName: "Alice"}),
+		alice.tx(t, model.TxTypeTransfer, model.TransferTx{To: "carol", Amount: 10}),
+	)
+	finalizeAndCommit(t, app, 2,
+		alice.tx(t, model.TxTypeEdit, model.EditTx{MessageID: 1, Message: "hello, edited"}),
+		bob.tx(t, model.TxTypeDelete, model.DeleteTx{MessageID: 3}),
+		carol.tx(t, model.TxTypeFlag, model.FlagTx{MessageID: 1, Reason: "off topic"}),
+	)
+	banTx, err = json.Marshal(model.BanTx{UserName: "bob", Reason: forum.BanReasonCurseWord})
+	require.NoError(t, err)
+	finalizeAndCommit(t, app, 3, banTx)
+	results := finalizeAndCommit(t, app, 4, bob.tx(t, model.TxTypeAppeal, model.AppealTx{Reason: "sorry"}))
+	require.Equal(t, forum.CodeTypeOK, results[0].Code)
+
+	exported, err := app.Export(0)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"bob", "mallory"}, exported.Bans)
+	require.Equal(t, []forum.GenesisFlag{{MessageID: 1, User: "carol", Reason: "off topic"}}, exported.Flags)
+	require.Equal(t, []forum.GenesisAppeal{{User: "bob", Reason: "sorry"}}, exported.Appeals)
+	require.Len(t, exported.Messages, 2)
+	require.Equal(t, uint64(3), exported.MessageCount)
+
+	// Restart as a new chain from the exported state
+	appState, err := json.Marshal(exported)
+	require.NoError(t, err)
+	restarted := newApp(t)
+	initChain(t, restarted, string(appState))
+
+	reexported, err := restarted.Export(0)
+	require.NoError(t, err)
+	require.Equal(t, exported, reexported)
+
+	u := queryUser(t, restarted, "alice")
+	require.True(t, u.Admin)
+	require.Equal(t, uint64(4), u.Nonce)
+	require.Equal(t, int64(1), u.NumMessages)
+	require.True(t, queryUser(t, restarted, "bob").Banned)
+	require.Equal(t, "sorry", queryUser(t, restarted, "bob").Appeal.Reason)
+	require.Equal(t, "89", queryBalance(t, restarted, "alice"))
+	require.Equal(t, "10", queryBalance(t, restarted, "carol"))
+
+	// Old transactions can't be replayed and new messages get new IDs
+	alice.nonce--
+	resp, err := restarted.CheckTx(context.Background(), &abci.RequestCheckTx{Tx: alice.post(t, "hello")})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeBadNonce, resp.Code)
+	results = finalizeAndCommit(t, restarted, 1, alice.post(t, "after the restart"))
+	require.Equal(t, forum.CodeTypeOK, results[0].Code)
+	require.Equal(t, "4", results[0].Events[0].Attributes[1].Value)
+}
+
+func TestExportAtPastHeight(t *testing.T) {
+	app := newApp(t)
+	alice := newAccount("alice")
+	initChain(t, app, `{`+genesisUsers(alice)+`}`)
+	finalizeAndCommit(t, app, 1, alice.post(t, "hello"))
+	finalizeAndCommit(t, app, 2, alice.post(t, "again"))
+
+	exported, err := app.Export(1)
+	require.NoError(t, err)
+	require.Len(t, exported.Messages, 1)
+	require.Equal(t, uint64(1), exported.Users[0].Nonce)
+}
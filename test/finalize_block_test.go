@@ -0,0 +1,55 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/stretchr/testify/require"
+
+	forum "github.com/alijnmerchant21/forum-updated/abci"
+	"github.com/alijnmerchant21/forum-updated/model"
+)
+
+func TestFinalizeBlockEvents(t *testing.T) {
+	app := newApp(t)
+	alice := newAccount("alice")
+	initChain(t, app, `{`+genesisUsers(alice)+`}`)
+
+	banTx, err := json.Marshal(model.BanTx{UserName: "mallory", Reason: forum.BanReasonCurseWord})
+	require.NoError(t, err)
+	txs := [][]byte{
+		banTx,
+		alice.post(t, "hello"),
+		alice.tx(t, model.TxTypePost, model.PostTx{Message: "world", Board: "news"}),
+	}
+	resp, err := app.FinalizeBlock(context.Background(), &abci.RequestFinalizeBlock{Txs: txs, Height: 1})
+	require.NoError(t, err)
+	require.Len(t, resp.TxResults, 3)
+
+	ban := resp.TxResults[0].Events
+	require.Len(t, ban, 1)
+	require.Equal(t, forum.EventTypeBan, ban[0].Type)
+	require.Contains(t, ban[0].Attributes, abci.EventAttribute{Key: forum.AttributeKeyUser, Value: "mallory", Index: true})
+	require.Contains(t, ban[0].Attributes, abci.EventAttribute{Key: forum.AttributeKeyReason, Value: forum.BanReasonCurseWord, Index: true})
+
+	post := resp.TxResults[2].Events
+	require.Len(t, post, 1)
+	require.Equal(t, forum.EventTypePost, post[0].Type)
+	require.Contains(t, post[0].Attributes, abci.EventAttribute{Key: forum.AttributeKeySender, Value: "alice", Index: true})
+	require.Contains(t, post[0].Attributes, abci.EventAttribute{Key: forum.AttributeKeyMessageID, Value: "2", Index: true})
+	require.Contains(t, post[0].Attributes, abci.EventAttribute{Key: forum.AttributeKeyBoard, Value: "news", Index: true})
+
+	_, err = app.Commit(context.Background(), &abci.RequestCommit{})
+	require.NoError(t, err)
+
+	// Both messages of the block are kept
+	query, err := app.Query(context.Background(), &abci.RequestQuery{Data: []byte("alice")})
+	require.NoError(t, err)
+	var messages []model.Message
+	require.NoError(t, json.Unmarshal(query.Value, &messages))
+	require.Len(t, messages, 2)
+	require.Equal(t, "hello", messages[0].Message)
+	require.Equal(t, "world", messages[1].Message)
+}
@@ -10,7 +10,7 @@ import (
 func TestFindUserByname(t *testing.T) {
 	// Initialize the database
 	println("DB to be initialized")
-	db, err := model.NewDB("test.db")
+	db, err := model.NewDB(t.TempDir())
 	println("DB initialized")
 	if err != nil {
 		t.Fatalf("Failed to create database: %v", err)
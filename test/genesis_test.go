@@ -0,0 +1,91 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/stretchr/testify/require"
+
+	forum "github.com/alijnmerchant21/forum-updated/abci"
+	"github.com/alijnmerchant21/forum-updated/model"
+)
+
+func TestGenesisState(t *testing.T) {
+	app := newApp(t)
+	ctx := context.Background()
+	alice, bob, carol := newAccount("alice"), newAccount("bob"), newAccount("carol")
+	initChain(t, app, `{
+		`+genesisUsers(alice, bob, carol)+`,
+		"moderators": ["bob"],
+		"admins": ["alice"],
+		"word_list": ["troll"],
+		"boards": [{"name": "general"}, {"name": "dev", "description": "Development"}]
+	}`)
+
+	require.True(t, queryUser(t, app, "alice").Admin)
+	require.False(t, queryUser(t, app, "alice").Moderator)
+	require.True(t, queryUser(t, app, "bob").Moderator)
+	require.False(t, queryUser(t, app, "carol").Moderator)
+
+	// Only the genesis boards can be posted to
+	resp, err := app.CheckTx(ctx, &abci.RequestCheckTx{Tx: carol.tx(t, model.TxTypePost, model.PostTx{Message: "hi", Board: "dev"})})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeOK, resp.Code)
+	resp, err = app.CheckTx(ctx, &abci.RequestCheckTx{Tx: carol.tx(t, model.TxTypePost, model.PostTx{Message: "hi", Board: "random"})})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeUnknownBoard, resp.Code)
+
+	// A block with a word of the word list and no ban is rejected
+	proposal, err := app.ProcessProposal(ctx, &abci.RequestProcessProposal{Height: 1, Txs: [][]byte{carol.post(t, "troll")}})
+	require.NoError(t, err)
+	require.Equal(t, abci.ResponseProcessProposal_REJECT, proposal.Status)
+	// Messages that only contain parts of the word are clean
+	proposal, err = app.ProcessProposal(ctx, &abci.RequestProcessProposal{Height: 1, Txs: [][]byte{carol.post(t, "roll"), bob.post(t, "a controlled trolley")}})
+	require.NoError(t, err)
+	require.Equal(t, abci.ResponseProcessProposal_ACCEPT, proposal.Status)
+}
+
+func TestAdminTransactions(t *testing.T) {
+	app := newApp(t)
+	ctx := context.Background()
+	alice, bob := newAccount("alice"), newAccount("bob")
+	initChain(t, app, `{`+genesisUsers(alice, bob)+`, "admins": ["alice"]}`)
+
+	resp, err := app.CheckTx(ctx, &abci.RequestCheckTx{Tx: bob.tx(t, model.TxTypeSetModerator, model.SetModeratorTx{User: "bob", Moderator: true})})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeUnauthorized, resp.Code)
+
+	results := finalizeAndCommit(t, app, 1,
+		alice.tx(t, model.TxTypeSetModerator, model.SetModeratorTx{User: "bob", Moderator: true}),
+		alice.tx(t, model.TxTypeWordList, model.WordListTx{Add: []string{"troll", "spam"}}),
+		alice.tx(t, model.TxTypeSetModerator, model.SetModeratorTx{User: "nobody", Moderator: true}),
+	)
+	require.Equal(t, forum.CodeTypeOK, results[0].Code)
+	require.Equal(t, forum.EventTypeModeratorChange, results[0].Events[0].Type)
+	require.Equal(t, forum.CodeTypeOK, results[1].Code)
+	require.Equal(t, forum.EventTypeWordListChange, results[1].Events[0].Type)
+	require.Equal(t, forum.CodeTypeUnknownUser, results[2].Code)
+	require.True(t, queryUser(t, app, "bob").Moderator)
+
+	proposal, err := app.ProcessProposal(ctx, &abci.RequestProcessProposal{Height: 2, Txs: [][]byte{bob.post(t, "spam")}})
+	require.NoError(t, err)
+	require.Equal(t, abci.ResponseProcessProposal_REJECT, proposal.Status)
+}
+
+func TestInvalidGenesis(t *testing.T) {
+	alice := newAccount("alice")
+	for name, appState := range map[string]string{
+		"unknown moderator": `{` + genesisUsers(alice) + `, "moderators": ["bob"]}`,
+		"unknown admin":     `{` + genesisUsers(alice) + `, "admins": ["bob"]}`,
+		"duplicate word":    `{"word_list": ["troll", "troll"]}`,
+		"invalid word":      `{"word_list": ["a|b"]}`,
+		"invalid board":     `{"boards": [{"name": "Not A Board"}]}`,
+		"duplicate board":   `{"boards": [{"name": "dev"}, {"name": "dev"}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := forum.ParseGenesisState([]byte(appState))
+			require.Error(t, err)
+		})
+	}
+}
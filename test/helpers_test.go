@@ -0,0 +1,122 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	cmtlog "github.com/cometbft/cometbft/libs/log"
+	"github.com/cometbft/cometbft/types"
+	"github.com/stretchr/testify/require"
+
+	forum "github.com/alijnmerchant21/forum-updated/abci"
+	"github.com/alijnmerchant21/forum-updated/model"
+)
+
+// appSetup is how newApp starts an app
+type appSetup struct {
+	home     string
+	config   string
+	fixture  string
+	logs     io.Writer
+	appState *string
+}
+
+type appOption func(*appSetup)
+
+// withConfig sets the content of app.toml, `curse_words = "bad"` by default
+func withConfig(config string) appOption {
+	return func(s *appSetup) { s.config = config }
+}
+
+// withHome puts app.toml and the database in dir instead of a new directory
+func withHome(dir string) appOption {
+	return func(s *appSetup) { s.home = dir }
+}
+
+// withFixture loads the records of a file of testdata into the database
+// before the app opens it
+func withFixture(name string) appOption {
+	return func(s *appSetup) { s.fixture = name }
+}
+
+// withLogs writes the logs of the app to w, with the log_level and
+// log_format of the config
+func withLogs(w io.Writer) appOption {
+	return func(s *appSetup) { s.logs = w }
+}
+
+// withGenesis runs InitChain with the app state
+func withGenesis(appState string) appOption {
+	return func(s *appSetup) { s.appState = &appState }
+}
+
+// newApp starts an app configured by the options, which is closed at the end
+// of the test
+func newApp(t *testing.T, opts ...appOption) *forum.ForumApp {
+	setup := appSetup{config: `curse_words = "bad"`}
+	for _, opt := range opts {
+		opt(&setup)
+	}
+	if setup.home == "" {
+		setup.home = t.TempDir()
+	}
+	configPath := filepath.Join(setup.home, "app.toml")
+	require.NoError(t, os.WriteFile(configPath, []byte(setup.config), 0o600))
+	appConfig, err := forum.LoadConfig(configPath)
+	require.NoError(t, err)
+	appConfig.SetRoot(setup.home)
+
+	if setup.fixture != "" {
+		db, err := model.OpenDB(appConfig.DBBackend, appConfig.DBPath())
+		require.NoError(t, err)
+		loadFixture(t, db, setup.fixture)
+		require.NoError(t, db.Close())
+	}
+
+	logger := cmtlog.NewNopLogger()
+	if setup.logs != nil {
+		logger, err = forum.NewLogger(appConfig, setup.logs)
+		require.NoError(t, err)
+	}
+	app, err := forum.NewForumApp(appConfig, logger)
+	require.NoError(t, err)
+	t.Cleanup(func() { app.Close() })
+
+	if setup.appState != nil {
+		initChain(t, app, *setup.appState)
+	}
+	return app
+}
+
+func initChain(t *testing.T, app *forum.ForumApp, appState string) {
+	params := types.DefaultConsensusParams().ToProto()
+	_, err := app.InitChain(context.Background(), &abci.RequestInitChain{
+		ConsensusParams: &params,
+		AppStateBytes:   []byte(appState),
+	})
+	require.NoError(t, err)
+}
+
+func newInMemoryDB(t *testing.T) *model.DB {
+	testDB := &model.DB{}
+	testDB.Init(model.NewMemoryStore())
+	t.Cleanup(func() { testDB.Close() })
+	return testDB
+}
+
+// loadFixture writes the records of a fixture file, a JSON object of keys
+// to values, to the database
+func loadFixture(t *testing.T, db model.KVStore, name string) {
+	fixture, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	var records map[string]string
+	require.NoError(t, json.Unmarshal(fixture, &records))
+	for key, value := range records {
+		require.NoError(t, db.Set([]byte(key), []byte(value)))
+	}
+}
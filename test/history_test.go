@@ -0,0 +1,142 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/stretchr/testify/require"
+
+	forum "github.com/alijnmerchant21/forum-updated/abci"
+	"github.com/alijnmerchant21/forum-updated/model"
+)
+
+func queryAt(app *forum.ForumApp, path string, data string, height int64) (*abci.ResponseQuery, error) {
+	return app.Query(context.Background(), &abci.RequestQuery{Path: path, Data: []byte(data), Height: height})
+}
+
+func TestQueryAtPastHeight(t *testing.T) {
+	app := newApp(t)
+	alice, bob := newAccount("alice"), newAccount("bob")
+	initChain(t, app, `{`+genesisUsers(alice, bob)+`}`)
+
+	finalizeAndCommit(t, app, 1, alice.post(t, "hello"))
+	banTx, err := json.Marshal(model.BanTx{UserName: "alice", Reason: forum.BanReasonCurseWord})
+	require.NoError(t, err)
+	finalizeAndCommit(t, app, 2, banTx, bob.post(t, "hi"))
+	finalizeAndCommit(t, app, 3, bob.post(t, "again"))
+
+	userAt := func(height int64) *model.User {
+		resp, err := queryAt(app, forum.QueryPathUser, "alice", height)
+		require.NoError(t, err)
+		var u model.User
+		require.NoError(t, json.Unmarshal(resp.Value, &u))
+		return &u
+	}
+	// The user as it was when the ban was issued, and right after
+	require.False(t, userAt(1).Banned)
+	require.Equal(t, int64(1), userAt(1).NumMessages)
+	require.True(t, userAt(2).Banned)
+	require.True(t, userAt(0).Banned)
+
+	messagesAt := func(height int64) []model.Message {
+		resp, err := queryAt(app, "", "bob", height)
+		require.NoError(t, err)
+		var messages []model.Message
+		require.NoError(t, json.Unmarshal(resp.Value, &messages))
+		return messages
+	}
+	resp, err := queryAt(app, "", "bob", 1)
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeNotFound, resp.Code)
+	require.Len(t, messagesAt(2), 1)
+	require.Len(t, messagesAt(3), 2)
+
+	resp, err = queryAt(app, forum.QueryPathUser, "bob", 2)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), resp.Height)
+	resp, err = queryAt(app, forum.QueryPathUser, "bob", 0)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), resp.Height)
+
+	resp, err = queryAt(app, forum.QueryPathUser, "bob", 4)
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeHeightUnavailable, resp.Code)
+}
+
+func TestHistoryPruning(t *testing.T) {
+	app := newApp(t, withConfig(`
+curse_words = "bad"
+
+[history]
+pruning = "custom"
+keep_recent = 2
+interval = 1
+`))
+	alice := newAccount("alice")
+	initChain(t, app, `{`+genesisUsers(alice)+`}`)
+	for height := int64(1); height <= 4; height++ {
+		finalizeAndCommit(t, app, height, alice.post(t, "hello"))
+	}
+
+	resp, err := queryAt(app, forum.QueryPathUser, "alice", 2)
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeHeightUnavailable, resp.Code)
+	resp, err = queryAt(app, forum.QueryPathUser, "alice", 3)
+	require.NoError(t, err)
+	var u model.User
+	require.NoError(t, json.Unmarshal(resp.Value, &u))
+	require.Equal(t, int64(3), u.NumMessages)
+}
+
+func TestHistoryDisabled(t *testing.T) {
+	app := newApp(t, withConfig(`
+curse_words = "bad"
+
+[history]
+pruning = "everything"
+`))
+	alice := newAccount("alice")
+	initChain(t, app, `{`+genesisUsers(alice)+`}`)
+	finalizeAndCommit(t, app, 1, alice.post(t, "hello"))
+	finalizeAndCommit(t, app, 2, alice.post(t, "hello"))
+
+	resp, err := queryAt(app, forum.QueryPathUser, "alice", 1)
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeHeightUnavailable, resp.Code)
+	resp, err = queryAt(app, forum.QueryPathUser, "alice", 2)
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeOK, resp.Code)
+}
+
+func TestVersionedIterate(t *testing.T) {
+	db := newInMemoryDB(t)
+	require.NoError(t, db.Set([]byte("val1"), []byte("a")))
+
+	block := db.NewCache()
+	require.NoError(t, block.Set([]byte("val2"), []byte("b")))
+	require.NoError(t, block.WriteVersion(1))
+	block = db.NewCache()
+	require.NoError(t, block.Delete([]byte("val1")))
+	require.NoError(t, block.Set([]byte("val2"), []byte("c")))
+	require.NoError(t, block.Set([]byte("val3"), []byte("d")))
+	require.NoError(t, block.WriteVersion(2))
+
+	state, err := db.AtHeight(1)
+	require.NoError(t, err)
+	values := make(map[string]string)
+	err = state.Iterate([]byte("val"), func(key, value []byte) error {
+		values[string(key)] = string(value)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"val1": "a", "val2": "b"}, values)
+	require.ErrorIs(t, state.Set([]byte("val1"), nil), model.ErrReadOnly)
+
+	pruned, err := db.PruneVersions(2)
+	require.NoError(t, err)
+	require.Equal(t, 4, pruned)
+	_, err = db.AtHeight(1)
+	require.ErrorIs(t, err, model.ErrHeightPruned)
+}
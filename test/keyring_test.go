@@ -0,0 +1,44 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alijnmerchant21/forum-updated/keyring"
+)
+
+func TestKeyring(t *testing.T) {
+	dir := t.TempDir()
+	kr, err := keyring.New(dir)
+	require.NoError(t, err)
+
+	alice, err := kr.Generate("alice")
+	require.NoError(t, err)
+	_, err = kr.Generate("alice")
+	require.Error(t, err, "keys are never replaced")
+	_, err = kr.Generate("not a name")
+	require.Error(t, err)
+
+	// Another keyring imports the key of alice under another name
+	other, err := keyring.New(t.TempDir())
+	require.NoError(t, err)
+	imported, err := other.Import("alice2", alice.PrivKey)
+	require.NoError(t, err)
+	require.Equal(t, alice.PubKey(), imported.PubKey())
+
+	_, err = kr.Generate("bob")
+	require.NoError(t, err)
+	reopened, err := keyring.New(dir)
+	require.NoError(t, err)
+	keys, err := reopened.List()
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	require.Equal(t, "alice", keys[0].Name)
+	require.Equal(t, alice.PrivKey, keys[0].PrivKey)
+	require.Equal(t, "bob", keys[1].Name)
+
+	_, err = kr.Get("carol")
+	require.True(t, errors.Is(err, keyring.ErrKeyNotFound))
+}
@@ -0,0 +1,188 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/cometbft/cometbft/crypto/ed25519"
+	"github.com/stretchr/testify/require"
+
+	forum "github.com/alijnmerchant21/forum-updated/abci"
+	"github.com/alijnmerchant21/forum-updated/model"
+)
+
+func queryUser(t *testing.T, app *forum.ForumApp, name string) *model.User {
+	resp, err := app.Query(context.Background(), &abci.RequestQuery{Path: forum.QueryPathUser, Data: []byte(name)})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeOK, resp.Code, resp.Log)
+	var u model.User
+	require.NoError(t, json.Unmarshal(resp.Value, &u))
+	return &u
+}
+
+func TestRotateKey(t *testing.T) {
+	app := newApp(t)
+	ctx := context.Background()
+	alice := newAccount("alice")
+	initChain(t, app, `{`+genesisUsers(alice)+`}`)
+
+	oldKey, newKey := alice.key, ed25519.GenPrivKey()
+	results := finalizeAndCommit(t, app, 1, alice.tx(t, model.TxTypeRotateKey, model.RotateKeyTx{PubKey: newKey.PubKey().Bytes()}))
+	require.Equal(t, forum.CodeTypeOK, results[0].Code)
+	require.Equal(t, forum.EventTypeKeyChange, results[0].Events[0].Type)
+
+	// The old key can't sign anymore
+	resp, err := app.CheckTx(ctx, &abci.RequestCheckTx{Tx: alice.post(t, "hello")})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeUnauthorized, resp.Code)
+	alice.nonce--
+	alice.key = newKey
+	resp, err = app.CheckTx(ctx, &abci.RequestCheckTx{Tx: alice.post(t, "hello")})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeOK, resp.Code)
+
+	query, err := app.Query(ctx, &abci.RequestQuery{Path: forum.QueryPathKeyHistory, Data: []byte("alice")})
+	require.NoError(t, err)
+	var history []model.KeyChange
+	require.NoError(t, json.Unmarshal(query.Value, &history))
+	require.Len(t, history, 1)
+	require.Equal(t, oldKey.PubKey().Bytes(), []byte(history[0].PubKey))
+	require.Equal(t, int64(1), history[0].Height)
+	require.Equal(t, model.KeyChangeRotation, history[0].Reason)
+}
+
+func TestRecoverWithRecoveryKey(t *testing.T) {
+	app := newApp(t)
+	ctx := context.Background()
+	alice := newAccount("alice")
+	recoveryKey, newKey := ed25519.GenPrivKey(), ed25519.GenPrivKey()
+	initChain(t, app, fmt.Sprintf(`{
+		"params": {"recovery": {"delay_blocks": 2}},
+		"users": [{"name": "alice", "pub_key": %q, "recovery_key": %q}]
+	}`, encodeKey(alice.key), encodeKey(recoveryKey)))
+
+	recover := model.RecoverTx{User: "alice", PubKey: newKey.PubKey().Bytes()}
+	// Only the recovery key can sign a recovery of the own account
+	resp, err := app.CheckTx(ctx, &abci.RequestCheckTx{Tx: alice.txSignedWith(t, newKey, model.TxTypeRecover, recover)})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeUnauthorized, resp.Code)
+	alice.nonce--
+
+	results := finalizeAndCommit(t, app, 1, alice.txSignedWith(t, recoveryKey, model.TxTypeRecover, recover))
+	require.Equal(t, forum.CodeTypeOK, results[0].Code)
+	require.Equal(t, forum.EventTypeRecoveryRequest, results[0].Events[0].Type)
+
+	// The key is only replaced after the delay
+	resp2, err := app.FinalizeBlock(ctx, &abci.RequestFinalizeBlock{Height: 2})
+	require.NoError(t, err)
+	require.Empty(t, resp2.Events)
+	_, err = app.Commit(ctx, &abci.RequestCommit{})
+	require.NoError(t, err)
+	require.Equal(t, alice.key.PubKey().Bytes(), []byte(queryUser(t, app, "alice").PubKey))
+
+	resp3, err := app.FinalizeBlock(ctx, &abci.RequestFinalizeBlock{Height: 3})
+	require.NoError(t, err)
+	require.Len(t, resp3.Events, 1)
+	require.Equal(t, forum.EventTypeKeyChange, resp3.Events[0].Type)
+	_, err = app.Commit(ctx, &abci.RequestCommit{})
+	require.NoError(t, err)
+
+	u := queryUser(t, app, "alice")
+	require.Equal(t, newKey.PubKey().Bytes(), []byte(u.PubKey))
+	require.Nil(t, u.PendingRecovery)
+	require.Equal(t, model.KeyChangeRecovery, u.KeyHistory[0].Reason)
+}
+
+func TestCancelRecovery(t *testing.T) {
+	app := newApp(t)
+	alice := newAccount("alice")
+	recoveryKey, newKey := ed25519.GenPrivKey(), ed25519.GenPrivKey()
+	initChain(t, app, `{"params": {"recovery": {"delay_blocks": 1}}, `+genesisUsers(alice)+`}`)
+
+	results := finalizeAndCommit(t, app, 1,
+		alice.tx(t, model.TxTypeSetRecovery, model.SetRecoveryTx{RecoveryKey: recoveryKey.PubKey().Bytes()}),
+		alice.txSignedWith(t, recoveryKey, model.TxTypeRecover, model.RecoverTx{User: "alice", PubKey: newKey.PubKey().Bytes()}),
+	)
+	require.Equal(t, forum.CodeTypeOK, results[0].Code)
+	require.Equal(t, forum.CodeTypeOK, results[1].Code)
+	require.NotNil(t, queryUser(t, app, "alice").PendingRecovery)
+
+	// The owner of the current key stops it before the delay ends
+	cancel := alice.tx(t, model.TxTypeCancelRecovery, model.CancelRecoveryTx{})
+	results = finalizeAndCommit(t, app, 2, cancel, alice.tx(t, model.TxTypeCancelRecovery, model.CancelRecoveryTx{}))
+	require.Equal(t, forum.CodeTypeOK, results[0].Code)
+	require.Equal(t, forum.CodeTypeNoRecovery, results[1].Code)
+
+	finalizeAndCommit(t, app, 3)
+	u := queryUser(t, app, "alice")
+	require.Equal(t, alice.key.PubKey().Bytes(), []byte(u.PubKey))
+	require.Empty(t, u.KeyHistory)
+}
+
+func TestRecoverByModerators(t *testing.T) {
+	app := newApp(t)
+	ctx := context.Background()
+	alice, bob := newAccount("alice"), newAccount("bob")
+	mod1, mod2 := newAccount("mod1"), newAccount("mod2")
+	newKey := ed25519.GenPrivKey()
+	initChain(t, app, fmt.Sprintf(`{
+		"params": {"recovery": {"delay_blocks": 0, "moderator_quorum": 2}},
+		"users": [
+			{"name": "alice", "pub_key": %q},
+			{"name": "bob", "pub_key": %q},
+			{"name": "mod1", "pub_key": %q, "moderator": true},
+			{"name": "mod2", "pub_key": %q, "moderator": true}
+		]
+	}`, encodeKey(alice.key), encodeKey(bob.key), encodeKey(mod1.key), encodeKey(mod2.key)))
+
+	recover := model.RecoverTx{User: "alice", PubKey: newKey.PubKey().Bytes()}
+	resp, err := app.CheckTx(ctx, &abci.RequestCheckTx{Tx: bob.tx(t, model.TxTypeRecover, recover)})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeUnauthorized, resp.Code)
+
+	// One approval is not enough
+	finalizeAndCommit(t, app, 1, mod1.tx(t, model.TxTypeRecover, recover))
+	require.Equal(t, alice.key.PubKey().Bytes(), []byte(queryUser(t, app, "alice").PubKey))
+
+	results := finalizeAndCommit(t, app, 2, mod2.tx(t, model.TxTypeRecover, recover))
+	require.Equal(t, forum.CodeTypeOK, results[0].Code)
+	require.Equal(t, newKey.PubKey().Bytes(), []byte(queryUser(t, app, "alice").PubKey))
+}
+
+func TestModeratorCantReplacePendingRecovery(t *testing.T) {
+	app := newApp(t)
+	ctx := context.Background()
+	alice, mod1, mod2 := newAccount("alice"), newAccount("mod1"), newAccount("mod2")
+	recoveryKey, newKey, otherKey := ed25519.GenPrivKey(), ed25519.GenPrivKey(), ed25519.GenPrivKey()
+	initChain(t, app, fmt.Sprintf(`{
+		"params": {"recovery": {"delay_blocks": 10, "moderator_quorum": 2}},
+		"users": [
+			{"name": "alice", "pub_key": %q, "recovery_key": %q},
+			{"name": "mod1", "pub_key": %q, "moderator": true},
+			{"name": "mod2", "pub_key": %q, "moderator": true}
+		]
+	}`, encodeKey(alice.key), encodeKey(recoveryKey), encodeKey(mod1.key), encodeKey(mod2.key)))
+
+	recover := model.RecoverTx{User: "alice", PubKey: newKey.PubKey().Bytes()}
+	finalizeAndCommit(t, app, 1, alice.txSignedWith(t, recoveryKey, model.TxTypeRecover, recover))
+
+	other := model.RecoverTx{User: "alice", PubKey: otherKey.PubKey().Bytes()}
+	resp, err := app.CheckTx(ctx, &abci.RequestCheckTx{Tx: mod1.tx(t, model.TxTypeRecover, other)})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeRecoveryPending, resp.Code)
+	mod1.nonce--
+	results := finalizeAndCommit(t, app, 2, mod1.tx(t, model.TxTypeRecover, other))
+	require.Equal(t, forum.CodeTypeRecoveryPending, results[0].Code)
+
+	// Approving the pending key is still allowed
+	results = finalizeAndCommit(t, app, 3, mod2.tx(t, model.TxTypeRecover, recover))
+	require.Equal(t, forum.CodeTypeOK, results[0].Code)
+	pending := queryUser(t, app, "alice").PendingRecovery
+	require.NotNil(t, pending)
+	require.Equal(t, newKey.PubKey().Bytes(), []byte(pending.PubKey))
+	require.True(t, pending.RecoveryKey)
+	require.EqualValues(t, 1, pending.Height)
+}
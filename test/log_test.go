@@ -0,0 +1,87 @@
+package test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/stretchr/testify/require"
+
+	forum "github.com/alijnmerchant21/forum-updated/abci"
+	"github.com/alijnmerchant21/forum-updated/model"
+)
+
+// newLoggingApp returns an app logging as JSON to the returned buffer
+func newLoggingApp(t *testing.T, logLevel string) (*forum.ForumApp, *bytes.Buffer) {
+	var buf bytes.Buffer
+	app := newApp(t, withConfig(fmt.Sprintf("log_format = \"json\"\nlog_level = %q", logLevel)), withLogs(&buf))
+	return app, &buf
+}
+
+// logEntries parses the JSON log lines with the given message
+func logEntries(t *testing.T, buf *bytes.Buffer, msg string) []map[string]interface{} {
+	var entries []map[string]interface{}
+	scanner := bufio.NewScanner(bytes.NewReader(buf.Bytes()))
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry), scanner.Text())
+		if entry["_msg"] == msg {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func TestLogTransactions(t *testing.T) {
+	app, buf := newLoggingApp(t, "forum:debug,*:error")
+	alice := newAccount("alice")
+	initChain(t, app, `{`+genesisUsers(alice)+`}`)
+
+	resp, err := app.CheckTx(context.Background(), &abci.RequestCheckTx{Tx: alice.post(t, "hello")})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeOK, resp.Code)
+	accepted := logEntries(t, buf, "accepted transaction")
+	require.Len(t, accepted, 1)
+	require.Equal(t, "alice", accepted[0]["sender"])
+	require.Equal(t, "debug", accepted[0]["level"])
+	require.Equal(t, "forum", accepted[0]["module"])
+
+	alice.nonce = 0
+	post := alice.post(t, "hello")
+	alice.nonce = 0
+	replayed := alice.post(t, "hello again")
+	finalizeAndCommit(t, app, 1, post, replayed)
+
+	executed := logEntries(t, buf, "executed transaction")
+	require.Len(t, executed, 1)
+	require.EqualValues(t, 1, executed[0]["height"])
+	require.EqualValues(t, 0, executed[0]["index"])
+	require.Equal(t, model.TxTypePost, executed[0]["type"])
+
+	failed := logEntries(t, buf, "failed transaction")
+	require.Len(t, failed, 1)
+	require.EqualValues(t, 1, failed[0]["index"])
+	require.Equal(t, "alice", failed[0]["sender"])
+	require.EqualValues(t, forum.CodeTypeBadNonce, failed[0]["code"])
+	require.Equal(t, "info", failed[0]["level"])
+}
+
+func TestLogLevel(t *testing.T) {
+	app, buf := newLoggingApp(t, "info")
+	alice := newAccount("alice")
+	initChain(t, app, `{`+genesisUsers(alice)+`}`)
+	finalizeAndCommit(t, app, 1, alice.post(t, "hello"))
+	require.Empty(t, logEntries(t, buf, "executed transaction"))
+
+	// The database logs with its own module
+	_, buf = newLoggingApp(t, "forum-db:none,*:debug")
+	require.Empty(t, logEntries(t, buf, "migrated data"))
+	_, buf = newLoggingApp(t, "info")
+	migrated := logEntries(t, buf, "migrated data")
+	require.NotEmpty(t, migrated)
+	require.Equal(t, "forum-db", migrated[0]["module"])
+}
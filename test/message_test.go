@@ -19,7 +19,7 @@ func TestAddAndGetMessages(t *testing.T) {
 
 	// Create a model.DB instance using the Badger database
 	modelDB := &model.DB{}
-	modelDB.Init(db)
+	modelDB.Init(model.NewBadgerStore(db))
 
 	// Add a message to the database
 	message := &model.Message{
@@ -89,7 +89,7 @@ func TestAddAndGetMessages(t *testing.T) {
 
 }
 
-func TestParseMessage(t *testing.T) {
+func TestParseSimpleMessage(t *testing.T) {
 
 	// Define a test message
 	testMessage := []byte("sender:alice,message:hello")
@@ -0,0 +1,184 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/cometbft/cometbft/crypto/ed25519"
+	"github.com/stretchr/testify/require"
+
+	forum "github.com/alijnmerchant21/forum-updated/abci"
+	"github.com/alijnmerchant21/forum-updated/model"
+)
+
+func requireMigrated(t *testing.T, app *forum.ForumApp) {
+	ctx := context.Background()
+	var messages []model.Message
+	query, err := app.Query(ctx, &abci.RequestQuery{Data: []byte("alice")})
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(query.Value, &messages))
+	require.Equal(t, []model.Message{
+		{ID: 1, Sender: "alice", Message: "hello", Board: model.DefaultBoard},
+		{ID: 2, Sender: "alice", Message: "world", Board: model.DefaultBoard},
+	}, messages)
+
+	u := queryUser(t, app, "bob")
+	require.Equal(t, model.LatestSchemaVersion(), u.SchemaVersion)
+}
+
+func TestMigrateFixture(t *testing.T) {
+	db := newInMemoryDB(t)
+	loadFixture(t, db, "schema_v0.json")
+
+	// A dry run reports the changes without making them
+	applied, changes, err := db.DryRunMigrations()
+	require.NoError(t, err)
+	require.Len(t, applied, model.LatestSchemaVersion())
+	require.Equal(t, 1, applied[0].Version)
+	require.NotEmpty(t, changes)
+	version, err := model.GetSchemaVersion(db)
+	require.NoError(t, err)
+	require.Equal(t, 0, version)
+	legacy, err := db.Get([]byte("alicemsg"))
+	require.NoError(t, err)
+	require.Equal(t, "hello;world", string(legacy))
+
+	applied, err = db.Migrate()
+	require.NoError(t, err)
+	require.Len(t, applied, model.LatestSchemaVersion())
+	version, err = model.GetSchemaVersion(db)
+	require.NoError(t, err)
+	require.Equal(t, model.LatestSchemaVersion(), version)
+
+	messages, err := model.GetMessagesBySender(db, "bob")
+	require.NoError(t, err)
+	require.Equal(t, []model.Message{{ID: 3, Sender: "bob", Message: "hi", Board: model.DefaultBoard}}, messages)
+	u, err := model.GetUser(db, "alice")
+	require.NoError(t, err)
+	require.Equal(t, model.LatestSchemaVersion(), u.SchemaVersion)
+	require.True(t, u.Moderator)
+
+	// Nothing left to do
+	applied, err = db.Migrate()
+	require.NoError(t, err)
+	require.Empty(t, applied)
+}
+
+func TestMigrateOnStartup(t *testing.T) {
+	app := newApp(t, withFixture("schema_v0.json"), withConfig(`migrate_on_startup = true`))
+	_, err := app.Info(context.Background(), &abci.RequestInfo{})
+	require.NoError(t, err)
+	requireMigrated(t, app)
+}
+
+func TestLayoutMigrationsRunOnStartup(t *testing.T) {
+	// Moving the records to their prefixed keys can't wait for a migration height
+	app := newApp(t, withFixture("schema_v0.json"), withConfig(`migrate_on_startup = false`))
+	info, err := app.Info(context.Background(), &abci.RequestInfo{})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), info.LastBlockHeight)
+	requireMigrated(t, app)
+}
+
+func TestLoadStateWithoutParams(t *testing.T) {
+	// The state of the fixture was saved before the parameters existed
+	app := newApp(t, withFixture("schema_v1.json"), withConfig(`migrate_on_startup = true`))
+	_, err := app.Info(context.Background(), &abci.RequestInfo{})
+	require.NoError(t, err)
+	// The next block saves the parameters the app runs with
+	finalizeAndCommit(t, app, 2)
+	var params forum.Params
+	queryJSON(t, app, forum.QueryPathParams, "", &params)
+	require.Equal(t, forum.DefaultParams(), params)
+	require.NoError(t, params.Validate())
+}
+
+func TestRecoveryDelayWithoutParams(t *testing.T) {
+	app := newApp(t, withFixture("schema_v1.json"), withConfig(`migrate_on_startup = true`))
+	_, err := app.Info(context.Background(), &abci.RequestInfo{})
+	require.NoError(t, err)
+	dave := newAccount("dave")
+	recoveryKey, newKey := ed25519.GenPrivKey(), ed25519.GenPrivKey()
+	results := finalizeAndCommit(t, app, 2,
+		dave.register(t),
+		dave.tx(t, model.TxTypeSetRecovery, model.SetRecoveryTx{RecoveryKey: recoveryKey.PubKey().Bytes()}),
+		dave.txSignedWith(t, recoveryKey, model.TxTypeRecover, model.RecoverTx{User: "dave", PubKey: newKey.PubKey().Bytes()}),
+	)
+	for _, r := range results {
+		require.Equal(t, forum.CodeTypeOK, r.Code, r.Log)
+	}
+
+	// The default delay applies, leaving the owner time to cancel
+	finalizeAndCommit(t, app, 3)
+	u := queryUser(t, app, "dave")
+	require.Equal(t, dave.key.PubKey().Bytes(), []byte(u.PubKey))
+	require.NotNil(t, u.PendingRecovery)
+}
+
+func TestMigrateKeyNamespaces(t *testing.T) {
+	db := newInMemoryDB(t)
+	loadFixture(t, db, "schema_v1.json")
+	applied, err := db.Migrate()
+	require.NoError(t, err)
+	require.Len(t, applied, 2)
+	require.Equal(t, 2, applied[0].Version)
+
+	// Nothing is left outside of the namespaces
+	var keys []string
+	require.NoError(t, db.Iterate(nil, func(key, _ []byte) error {
+		keys = append(keys, string(key))
+		return nil
+	}))
+	require.ElementsMatch(t, []string{
+		"user/alice", "msg/alice", "profile/alice", "rate/alice",
+		"user/valerie",
+		"meta/history", "meta/appstate", "meta/schemaversion",
+		// The list of posters became the leaderboard index
+		string(model.RankKey(&model.User{Name: "alice", NumMessages: 1})),
+	}, keys)
+
+	u, err := model.GetUser(db, "valerie")
+	require.NoError(t, err)
+	require.Equal(t, "valerie", u.Name)
+	messages, err := model.GetMessagesBySender(db, "alice")
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	profile, err := model.GetProfile(db, "alice")
+	require.NoError(t, err)
+	require.Equal(t, "Alice", profile.DisplayName)
+	history, err := model.FetchHistory(db)
+	require.NoError(t, err)
+	require.Equal(t, "{sender:alice,message:hello}", history)
+}
+
+func TestSystemNamesDoNotCollide(t *testing.T) {
+	app := newApp(t)
+	appstate, alicemsg, alice := newAccount("appstate"), newAccount("alicemsg"), newAccount("alice")
+	initChain(t, app, `{`+genesisUsers(appstate, alicemsg, alice)+`}`)
+
+	results := finalizeAndCommit(t, app, 1, alice.post(t, "hello"), alicemsg.post(t, "hi"), appstate.post(t, "hey"))
+	for _, r := range results {
+		require.Equal(t, forum.CodeTypeOK, r.Code)
+	}
+	require.Equal(t, "alicemsg", queryUser(t, app, "alicemsg").Name)
+	require.Equal(t, "appstate", queryUser(t, app, "appstate").Name)
+	info, err := app.Info(context.Background(), &abci.RequestInfo{})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), info.LastBlockHeight)
+}
+
+func TestNewRecordsUseLatestSchema(t *testing.T) {
+	app := newApp(t)
+	alice := newAccount("alice")
+	initChain(t, app, `{`+genesisUsers(alice)+`}`)
+	require.Equal(t, model.LatestSchemaVersion(), queryUser(t, app, "alice").SchemaVersion)
+}
+
+func TestExportNeedsMigrations(t *testing.T) {
+	db := newInMemoryDB(t)
+	loadFixture(t, db, "schema_v0.json")
+	_, err := forum.ExportGenesis(db)
+	require.ErrorContains(t, err, "migrations")
+}
@@ -0,0 +1,132 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/stretchr/testify/require"
+
+	forum "github.com/alijnmerchant21/forum-updated/abci"
+	"github.com/alijnmerchant21/forum-updated/model"
+)
+
+func TestReplyAndFlag(t *testing.T) {
+	app := newApp(t)
+	alice, bob := newAccount("alice"), newAccount("bob")
+	initChain(t, app, `{`+genesisUsers(alice, bob)+`}`)
+
+	results := finalizeAndCommit(t, app, 1,
+		alice.post(t, "hello"),
+		bob.tx(t, model.TxTypePost, model.PostTx{Message: "hi alice", ReplyTo: 1}),
+		bob.tx(t, model.TxTypePost, model.PostTx{Message: "hi nobody", ReplyTo: 7}),
+	)
+	require.Equal(t, forum.CodeTypeOK, results[0].Code)
+	require.Equal(t, forum.CodeTypeOK, results[1].Code)
+	require.Equal(t, forum.AttributeKeyReplyTo, results[1].Events[0].Attributes[3].Key)
+	require.Equal(t, "1", results[1].Events[0].Attributes[3].Value)
+	require.Equal(t, forum.CodeTypeUnknownMessage, results[2].Code)
+	bob.nonce--
+
+	query, err := app.Query(context.Background(), &abci.RequestQuery{Data: []byte("bob")})
+	require.NoError(t, err)
+	var messages []model.Message
+	require.NoError(t, json.Unmarshal(query.Value, &messages))
+	require.Equal(t, uint64(1), messages[0].ReplyTo)
+
+	results = finalizeAndCommit(t, app, 2,
+		alice.tx(t, model.TxTypeFlag, model.FlagTx{MessageID: 2, Reason: "off topic"}),
+		alice.tx(t, model.TxTypeFlag, model.FlagTx{MessageID: 2}),
+		bob.tx(t, model.TxTypeFlag, model.FlagTx{MessageID: 3}),
+	)
+	require.Equal(t, forum.CodeTypeOK, results[0].Code)
+	require.Equal(t, forum.EventTypeFlag, results[0].Events[0].Type)
+	require.Equal(t, forum.CodeTypeDuplicate, results[1].Code)
+	require.Equal(t, forum.CodeTypeUnknownMessage, results[2].Code)
+
+	query, err = app.Query(context.Background(), &abci.RequestQuery{Path: forum.QueryPathFlags, Data: []byte("2")})
+	require.NoError(t, err)
+	var flags []model.Flag
+	require.NoError(t, json.Unmarshal(query.Value, &flags))
+	require.Equal(t, []model.Flag{{User: "alice", Reason: "off topic", Height: 2}}, flags)
+}
+
+func TestAppeal(t *testing.T) {
+	app := newApp(t)
+	ctx := context.Background()
+	alice, carol, mod := newAccount("alice"), newAccount("carol"), newAccount("mod")
+	initChain(t, app, fmt.Sprintf(`{%s, "moderators": ["mod"], "bans": ["carol"]}`, genesisUsers(alice, carol, mod)))
+
+	// Banned users can only appeal
+	resp, err := app.CheckTx(ctx, &abci.RequestCheckTx{Tx: carol.post(t, "hello")})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeBanned, resp.Code)
+	carol.nonce--
+	appeal := carol.tx(t, model.TxTypeAppeal, model.AppealTx{Reason: "it was a typo"})
+	resp, err = app.CheckTx(ctx, &abci.RequestCheckTx{Tx: appeal})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeOK, resp.Code)
+
+	resp, err = app.CheckTx(ctx, &abci.RequestCheckTx{Tx: alice.tx(t, model.TxTypeAppeal, model.AppealTx{Reason: "not banned"})})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeUnauthorized, resp.Code)
+	alice.nonce--
+
+	results := finalizeAndCommit(t, app, 1,
+		appeal,
+		carol.tx(t, model.TxTypeAppeal, model.AppealTx{Reason: "please"}),
+		alice.tx(t, model.TxTypeResolveAppeal, model.ResolveAppealTx{User: "carol", Accept: true}),
+	)
+	require.Equal(t, forum.CodeTypeOK, results[0].Code)
+	require.Equal(t, forum.EventTypeAppeal, results[0].Events[0].Type)
+	require.Equal(t, forum.CodeTypeDuplicate, results[1].Code)
+	require.Equal(t, forum.CodeTypeUnauthorized, results[2].Code)
+	carol.nonce--
+	alice.nonce--
+	require.Equal(t, &model.Appeal{Reason: "it was a typo", Height: 1}, queryUser(t, app, "carol").Appeal)
+
+	results = finalizeAndCommit(t, app, 2,
+		mod.tx(t, model.TxTypeResolveAppeal, model.ResolveAppealTx{User: "carol", Accept: true}),
+		mod.tx(t, model.TxTypeResolveAppeal, model.ResolveAppealTx{User: "carol"}),
+		carol.post(t, "back again"),
+	)
+	require.Equal(t, forum.CodeTypeOK, results[0].Code)
+	require.Equal(t, forum.EventTypeAppealResolved, results[0].Events[0].Type)
+	require.Equal(t, forum.EventTypeUnban, results[0].Events[1].Type)
+	require.Equal(t, forum.CodeTypeNoAppeal, results[1].Code)
+	require.Equal(t, forum.CodeTypeOK, results[2].Code)
+
+	u := queryUser(t, app, "carol")
+	require.False(t, u.Banned)
+	require.Nil(t, u.Appeal)
+}
+
+func TestWordFilterTxTypes(t *testing.T) {
+	app := newApp(t)
+	initChain(t, app, `{"word_list": ["bad"]}`)
+
+	for _, tt := range []struct {
+		txType string
+		data   func(text string) interface{}
+	}{
+		{model.TxTypePost, func(text string) interface{} { return model.PostTx{Message: text} }},
+		{model.TxTypeEdit, func(text string) interface{} { return model.EditTx{MessageID: 1, Message: text} }},
+		{model.TxTypeProfile, func(text string) interface{} { return model.Profile{DisplayName: "Name", Bio: text} }},
+		{model.TxTypeFlag, func(text string) interface{} { return model.FlagTx{MessageID: 1, Reason: text} }},
+		{model.TxTypeBanUser, func(text string) interface{} { return model.BanUserTx{User: "carol", Reason: text} }},
+		{model.TxTypeAppeal, func(text string) interface{} { return model.AppealTx{Reason: text} }},
+	} {
+		// A short text that is part of a curse word goes through, a curse word doesn't
+		clean, dirty := newAccount("clean"), newAccount("dirty")
+		txs := [][]byte{clean.tx(t, tt.txType, tt.data("ad")), dirty.tx(t, tt.txType, tt.data("you are bad"))}
+		resp, err := app.PrepareProposal(context.Background(), &abci.RequestPrepareProposal{Txs: txs, MaxTxBytes: 1 << 20})
+		require.NoError(t, err)
+		require.Len(t, resp.Txs, 2, tt.txType)
+		var ban model.BanTx
+		require.NoError(t, json.Unmarshal(resp.Txs[0], &ban))
+		require.Equal(t, "dirty", ban.UserName, tt.txType)
+		require.Equal(t, txs[0], resp.Txs[1], tt.txType)
+	}
+}
@@ -0,0 +1,89 @@
+package test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cometbft/cometbft/types"
+	"github.com/stretchr/testify/require"
+
+	forum "github.com/alijnmerchant21/forum-updated/abci"
+	"github.com/alijnmerchant21/forum-updated/node"
+)
+
+func TestInitFiles(t *testing.T) {
+	home := t.TempDir()
+	require.NoError(t, node.InitFiles(home, "forum-test"))
+
+	config, err := node.LoadConfig(home)
+	require.NoError(t, err)
+	genDoc, err := types.GenesisDocFromFile(config.GenesisFile())
+	require.NoError(t, err)
+	require.Equal(t, "forum-test", genDoc.ChainID)
+	require.Len(t, genDoc.Validators, 1)
+	_, err = forum.ParseGenesisState(genDoc.AppState)
+	require.NoError(t, err)
+
+	appConfig, err := forum.LoadConfig(node.AppConfigFile(home))
+	require.NoError(t, err)
+	require.Equal(t, "forum-test", appConfig.ChainID)
+	require.Equal(t, forum.DefaultConfig().CurseWords, appConfig.CurseWords)
+
+	// Running it again keeps the keys and the genesis
+	require.NoError(t, node.InitFiles(home, "other-chain"))
+	again, err := types.GenesisDocFromFile(config.GenesisFile())
+	require.NoError(t, err)
+	require.Equal(t, genDoc.Validators[0].PubKey, again.Validators[0].PubKey)
+	require.Equal(t, "forum-test", again.ChainID)
+}
+
+func TestSenderLimitNeedsRecheck(t *testing.T) {
+	home := t.TempDir()
+	require.NoError(t, node.InitFiles(home, "forum-test"))
+	config, err := node.LoadConfig(home)
+	require.NoError(t, err)
+	appConfig, err := node.LoadAppConfig(home)
+	require.NoError(t, err)
+
+	appConfig.Mempool.MaxTxsPerSender = 2
+	require.NoError(t, node.ValidateConfig(config, appConfig))
+	config.Mempool.Recheck = false
+	require.ErrorContains(t, node.ValidateConfig(config, appConfig), "recheck")
+	appConfig.Mempool.MaxTxsPerSender = 0
+	require.NoError(t, node.ValidateConfig(config, appConfig))
+}
+
+func TestInitTestnet(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, node.InitTestnet(dir, 3, "forum-testnet"))
+
+	var genesis []byte
+	httpAddrs := make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		home := node.NodeHome(dir, i)
+		config, err := node.LoadConfig(home)
+		require.NoError(t, err)
+		require.Len(t, strings.Split(config.P2P.PersistentPeers, ","), 2)
+		require.NotContains(t, config.P2P.PersistentPeers, config.P2P.ListenAddress[len("tcp://"):])
+		require.True(t, config.P2P.AllowDuplicateIP)
+
+		// All the nodes share one genesis with the three validators
+		bz, err := os.ReadFile(config.GenesisFile())
+		require.NoError(t, err)
+		if genesis == nil {
+			genesis = bz
+		}
+		require.Equal(t, genesis, bz)
+		genDoc, err := types.GenesisDocFromFile(config.GenesisFile())
+		require.NoError(t, err)
+		require.Len(t, genDoc.Validators, 3)
+
+		appConfig, err := forum.LoadConfig(node.AppConfigFile(home))
+		require.NoError(t, err)
+		require.False(t, httpAddrs[appConfig.HTTPAddress])
+		httpAddrs[appConfig.HTTPAddress] = true
+	}
+
+	require.Error(t, node.InitTestnet(t.TempDir(), 0, "forum-testnet"))
+}
@@ -0,0 +1,71 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/stretchr/testify/require"
+
+	forum "github.com/alijnmerchant21/forum-updated/abci"
+	"github.com/alijnmerchant21/forum-updated/model"
+)
+
+func TestUpdateProfile(t *testing.T) {
+	app := newApp(t)
+	ctx := context.Background()
+	alice := newAccount("alice")
+	initChain(t, app, `{`+genesisUsers(alice)+`}`)
+
+	profile := model.Profile{
+		DisplayName: "Alice",
+		Bio:         "Posting cool stuff",
+		Links:       []string{"https://example.com/alice"},
+		Avatar:      strings.Repeat("ab", 32),
+	}
+	results := finalizeAndCommit(t, app, 1, alice.tx(t, model.TxTypeProfile, profile))
+	require.Equal(t, forum.CodeTypeOK, results[0].Code)
+	require.Equal(t, forum.EventTypeProfile, results[0].Events[0].Type)
+
+	query, err := app.Query(ctx, &abci.RequestQuery{Path: forum.QueryPathProfile, Data: []byte("alice")})
+	require.NoError(t, err)
+	var stored model.Profile
+	require.NoError(t, json.Unmarshal(query.Value, &stored))
+	require.Equal(t, profile, stored)
+
+	for _, invalid := range []model.Profile{
+		{DisplayName: strings.Repeat("a", model.MaxDisplayNameLength+1)},
+		{Bio: strings.Repeat("a", model.MaxBioLength+1)},
+		{Links: []string{"javascript:alert(1)"}},
+		{Avatar: "not a hash"},
+	} {
+		resp, err := app.CheckTx(ctx, &abci.RequestCheckTx{Tx: alice.tx(t, model.TxTypeProfile, invalid)})
+		require.NoError(t, err)
+		require.Equal(t, forum.CodeTypeInvalidTxFormat, resp.Code)
+	}
+}
+
+func TestProfileWordFilter(t *testing.T) {
+	app := newApp(t)
+	alice, bob := newAccount("alice"), newAccount("bob")
+	initChain(t, app, `{`+genesisUsers(alice, bob)+`}`)
+
+	txs := [][]byte{
+		alice.tx(t, model.TxTypeProfile, model.Profile{DisplayName: "bad"}),
+		alice.post(t, "hello"),
+		bob.tx(t, model.TxTypeProfile, model.Profile{DisplayName: "Bob"}),
+	}
+	resp, err := app.PrepareProposal(context.Background(), &abci.RequestPrepareProposal{
+		Txs:             txs,
+		MaxTxBytes:      1 << 20,
+		LocalLastCommit: abci.ExtendedCommitInfo{Votes: []abci.ExtendedVoteInfo{{VoteExtension: []byte("bad")}}},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Txs, 2)
+	var ban model.BanTx
+	require.NoError(t, json.Unmarshal(resp.Txs[0], &ban))
+	require.Equal(t, "alice", ban.UserName)
+	require.Equal(t, txs[2], resp.Txs[1])
+}
@@ -0,0 +1,84 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/stretchr/testify/require"
+
+	forum "github.com/alijnmerchant21/forum-updated/abci"
+	"github.com/alijnmerchant21/forum-updated/model"
+)
+
+func queryJSON(t *testing.T, app *forum.ForumApp, path string, data string, result interface{}) {
+	resp, err := app.Query(context.Background(), &abci.RequestQuery{Path: path, Data: []byte(data)})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeOK, resp.Code, resp.Log)
+	require.NoError(t, json.Unmarshal(resp.Value, result))
+}
+
+func TestQueryPaths(t *testing.T) {
+	app := newApp(t)
+	alice, bob := newAccount("alice"), newAccount("bob")
+	initChain(t, app, fmt.Sprintf(`{%s, "moderators": ["bob"], "bans": ["carol"], "params": {"rate_limit": {"max_posts": 5, "window_blocks": 10}}}`, genesisUsers(alice, bob)))
+	finalizeAndCommit(t, app, 1, alice.post(t, "one"), alice.post(t, "two, with a comma"), bob.post(t, "three"))
+
+	var names []string
+	queryJSON(t, app, forum.QueryPathBans, "", &names)
+	require.Equal(t, []string{"carol"}, names)
+	queryJSON(t, app, forum.QueryPathModerators, "", &names)
+	require.Equal(t, []string{"bob"}, names)
+
+	var params forum.Params
+	queryJSON(t, app, forum.QueryPathParams, "", &params)
+	require.Equal(t, uint64(5), params.RateLimit.MaxPosts)
+
+	var proposals []model.UpgradeProposal
+	queryJSON(t, app, forum.QueryPathProposals, "", &proposals)
+	require.Empty(t, proposals)
+
+	var messages []model.Message
+	queryJSON(t, app, forum.QueryPathMessages, "alice", &messages)
+	require.Len(t, messages, 2)
+	// Queries without a path still return the messages of the user
+	queryJSON(t, app, "", "alice", &messages)
+	require.Len(t, messages, 2)
+
+	var page model.HistoryPage
+	queryJSON(t, app, forum.QueryPathHistory, "", &page)
+	require.Equal(t, 3, page.Total)
+	require.Len(t, page.Entries, 3)
+	queryJSON(t, app, forum.QueryPathHistory, "2/2", &page)
+	require.Equal(t, []model.HistoryEntry{{Sender: "bob", Message: "three"}}, page.Entries)
+	queryJSON(t, app, forum.QueryPathHistory, "1/2", &page)
+	require.Equal(t, model.HistoryEntry{Sender: "alice", Message: "two, with a comma"}, page.Entries[1])
+	queryJSON(t, app, forum.QueryPathHistory, "5", &page)
+	require.Empty(t, page.Entries)
+
+	for _, data := range []string{"0", "x", "1/0", "1/1000"} {
+		resp, err := app.Query(context.Background(), &abci.RequestQuery{Path: forum.QueryPathHistory, Data: []byte(data)})
+		require.NoError(t, err)
+		require.Equal(t, forum.CodeTypeInvalidQuery, resp.Code, data)
+	}
+	resp, err := app.Query(context.Background(), &abci.RequestQuery{Path: "/nothing"})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeInvalidQuery, resp.Code)
+}
+
+func TestQueryUnknownUser(t *testing.T) {
+	app := newApp(t)
+	alice := newAccount("alice")
+	initChain(t, app, `{`+genesisUsers(alice)+`}`)
+	finalizeAndCommit(t, app, 1, alice.post(t, "hello"))
+
+	// A failed query is a response with a code, which keeps the connection open
+	resp, err := app.Query(context.Background(), &abci.RequestQuery{Path: forum.QueryPathUser, Data: []byte("bob")})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeNotFound, resp.Code)
+	require.NotEmpty(t, resp.Log)
+	require.Equal(t, int64(1), resp.Height)
+	require.Empty(t, resp.Value)
+}
@@ -0,0 +1,57 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/stretchr/testify/require"
+
+	forum "github.com/alijnmerchant21/forum-updated/abci"
+)
+
+func TestRateLimit(t *testing.T) {
+	app := newApp(t)
+	ctx := context.Background()
+	alice, bob := newAccount("alice"), newAccount("bob")
+	initChain(t, app, `{"params": {"rate_limit": {"max_posts": 2, "window_blocks": 3}}, `+genesisUsers(alice, bob)+`}`)
+
+	posts := [][]byte{alice.post(t, "one"), alice.post(t, "two"), alice.post(t, "three")}
+
+	// A proposal with three posts by alice is rejected
+	process, err := app.ProcessProposal(ctx, &abci.RequestProcessProposal{Txs: posts, Height: 1})
+	require.NoError(t, err)
+	require.Equal(t, abci.ResponseProcessProposal_REJECT, process.Status)
+
+	// and we never propose one
+	prepare, err := app.PrepareProposal(ctx, &abci.RequestPrepareProposal{Txs: posts, Height: 1, MaxTxBytes: 1024})
+	require.NoError(t, err)
+	require.Equal(t, posts[:2], prepare.Txs)
+
+	// Blocks that were not processed by us are still held to the limit
+	res, err := app.FinalizeBlock(ctx, &abci.RequestFinalizeBlock{Txs: posts, Height: 1})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeOK, res.TxResults[0].Code)
+	require.Equal(t, forum.CodeTypeOK, res.TxResults[1].Code)
+	require.Equal(t, forum.CodeTypeRateLimited, res.TxResults[2].Code)
+	_, err = app.Commit(ctx, &abci.RequestCommit{})
+	require.NoError(t, err)
+
+	// The failed post did not use up its nonce
+	alice.nonce--
+	four := alice.post(t, "four")
+	check, err := app.CheckTx(ctx, &abci.RequestCheckTx{Tx: four})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeRateLimited, check.Code)
+	check, err = app.CheckTx(ctx, &abci.RequestCheckTx{Tx: bob.post(t, "one")})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeOK, check.Code)
+
+	// Once the posts leave the window alice can post again
+	for height := int64(2); height <= 3; height++ {
+		finalizeAndCommit(t, app, height)
+	}
+	check, err = app.CheckTx(ctx, &abci.RequestCheckTx{Tx: four})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeOK, check.Code)
+}
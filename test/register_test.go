@@ -0,0 +1,140 @@
+package test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/cometbft/cometbft/crypto/ed25519"
+	"github.com/stretchr/testify/require"
+
+	forum "github.com/alijnmerchant21/forum-updated/abci"
+	"github.com/alijnmerchant21/forum-updated/model"
+)
+
+// testAccount signs transactions for a user
+type testAccount struct {
+	name  string
+	key   ed25519.PrivKey
+	nonce uint64
+}
+
+func newAccount(name string) *testAccount {
+	return &testAccount{name: name, key: ed25519.GenPrivKey()}
+}
+
+// genesisUsers returns the "users" entry of a genesis app_state registering the accounts
+func genesisUsers(accounts ...*testAccount) string {
+	users := make([]string, len(accounts))
+	for i, a := range accounts {
+		users[i] = fmt.Sprintf(`{"name": %q, "pub_key": %q}`, a.name, encodeKey(a.key))
+	}
+	return `"users": [` + strings.Join(users, ",") + `]`
+}
+
+func encodeKey(key ed25519.PrivKey) string {
+	return base64.StdEncoding.EncodeToString(key.PubKey().Bytes())
+}
+
+// tx signs a transaction of the account with its next nonce
+func (a *testAccount) tx(t *testing.T, txType string, data interface{}) []byte {
+	return a.txSignedWith(t, a.key, txType, data)
+}
+
+func (a *testAccount) txSignedWith(t *testing.T, key ed25519.PrivKey, txType string, data interface{}) []byte {
+	tx, err := model.NewTx(txType, a.name, a.nonce, data)
+	require.NoError(t, err)
+	require.NoError(t, tx.Sign(key))
+	a.nonce++
+	txBytes, err := tx.Bytes()
+	require.NoError(t, err)
+	return txBytes
+}
+
+func (a *testAccount) register(t *testing.T) []byte {
+	return a.tx(t, model.TxTypeRegister, model.RegisterTx{PubKey: a.key.PubKey().Bytes()})
+}
+
+func (a *testAccount) post(t *testing.T, message string) []byte {
+	return a.tx(t, model.TxTypePost, model.PostTx{Message: message})
+}
+
+func finalizeAndCommit(t *testing.T, app *forum.ForumApp, height int64, txs ...[]byte) []*abci.ExecTxResult {
+	resp, err := app.FinalizeBlock(context.Background(), &abci.RequestFinalizeBlock{Txs: txs, Height: height})
+	require.NoError(t, err)
+	_, err = app.Commit(context.Background(), &abci.RequestCommit{})
+	require.NoError(t, err)
+	return resp.TxResults
+}
+
+func TestRegisterUser(t *testing.T) {
+	app := newApp(t)
+	ctx := context.Background()
+	initChain(t, app, `{}`)
+	alice := newAccount("alice")
+
+	// Posting needs an account
+	early := newAccount("alice")
+	early.key = alice.key
+	early.nonce = 1
+	resp, err := app.CheckTx(ctx, &abci.RequestCheckTx{Tx: early.post(t, "hello")})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeUnknownUser, resp.Code)
+
+	// Unsigned posts are not accepted anymore
+	resp, err = app.CheckTx(ctx, &abci.RequestCheckTx{Tx: []byte("sender:alice,message:hello")})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeUnauthorized, resp.Code)
+
+	register := alice.register(t)
+	resp, err = app.CheckTx(ctx, &abci.RequestCheckTx{Tx: register})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeOK, resp.Code)
+
+	// Someone else trying to take the name in the same block
+	impostor := newAccount("alice")
+	results := finalizeAndCommit(t, app, 1, register, impostor.register(t), alice.post(t, "hello"))
+	require.Equal(t, forum.CodeTypeOK, results[0].Code)
+	require.Equal(t, forum.EventTypeRegister, results[0].Events[0].Type)
+	require.Equal(t, forum.CodeTypeUserExists, results[1].Code)
+	require.Equal(t, forum.CodeTypeOK, results[2].Code)
+
+	query, err := app.Query(ctx, &abci.RequestQuery{Path: forum.QueryPathUser, Data: []byte("alice")})
+	require.NoError(t, err)
+	var u model.User
+	require.NoError(t, json.Unmarshal(query.Value, &u))
+	require.Equal(t, alice.key.PubKey().Bytes(), []byte(u.PubKey))
+	require.Equal(t, uint64(2), u.Nonce)
+}
+
+func TestSignaturesAndNonces(t *testing.T) {
+	app := newApp(t)
+	ctx := context.Background()
+	alice := newAccount("alice")
+	initChain(t, app, `{`+genesisUsers(alice)+`}`)
+
+	// Signed with the wrong key
+	forged := newAccount("alice")
+	resp, err := app.CheckTx(ctx, &abci.RequestCheckTx{Tx: forged.post(t, "hello")})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeUnauthorized, resp.Code)
+
+	// Tampering with a signed transaction breaks the signature
+	post := alice.post(t, "hello")
+	tampered := []byte(strings.Replace(string(post), "hello", "hi", 1))
+	resp, err = app.CheckTx(ctx, &abci.RequestCheckTx{Tx: tampered})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeUnauthorized, resp.Code)
+
+	// Replaying a transaction fails on the nonce
+	results := finalizeAndCommit(t, app, 1, post, post)
+	require.Equal(t, forum.CodeTypeOK, results[0].Code)
+	require.Equal(t, forum.CodeTypeBadNonce, results[1].Code)
+	resp, err = app.CheckTx(ctx, &abci.RequestCheckTx{Tx: post})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeBadNonce, resp.Code)
+}
@@ -0,0 +1,72 @@
+package test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/stretchr/testify/require"
+
+	forum "github.com/alijnmerchant21/forum-updated/abci"
+)
+
+// voteExtensionWords returns the curse words the app puts in its vote extensions, sorted
+func voteExtensionWords(t *testing.T, app *forum.ForumApp) []string {
+	resp, err := app.ExtendVote(context.Background(), &abci.RequestExtendVote{})
+	require.NoError(t, err)
+	words := strings.Split(string(resp.VoteExtension), "|")
+	sort.Strings(words)
+	return words
+}
+
+func TestReloadCurseWords(t *testing.T) {
+	home := t.TempDir()
+	app := newApp(t, withHome(home), withConfig(`curse_words = "bad|cry"`), withGenesis(`{}`))
+	file := filepath.Join(home, "app.toml")
+	require.Equal(t, []string{"bad", "cry"}, voteExtensionWords(t, app))
+
+	require.Error(t, app.ReloadCurseWords("bad||rain"))
+	require.Error(t, app.ReloadCurseWords(strings.Repeat("x", 65)))
+
+	// The new words wait for the end of the block
+	_, err := app.FinalizeBlock(context.Background(), &abci.RequestFinalizeBlock{Height: 1})
+	require.NoError(t, err)
+	require.NoError(t, app.ReloadCurseWords("bad|rain|rain"))
+	require.Equal(t, []string{"bad", "cry"}, voteExtensionWords(t, app))
+	_, err = app.Commit(context.Background(), &abci.RequestCommit{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"bad", "rain"}, voteExtensionWords(t, app))
+
+	// An invalid file keeps the current words
+	require.NoError(t, os.WriteFile(file, []byte(`curse_words = "a|"`), 0o600))
+	require.Error(t, app.ReloadConfig(file))
+	require.NoError(t, os.WriteFile(file, []byte(`curse_words = "muggle"`), 0o600))
+	require.NoError(t, app.ReloadConfig(file))
+	finalizeAndCommit(t, app, 2)
+	require.Equal(t, []string{"muggle"}, voteExtensionWords(t, app))
+}
+
+func TestWatchConfig(t *testing.T) {
+	home := t.TempDir()
+	app := newApp(t, withHome(home), withGenesis(`{}`))
+	file := filepath.Join(home, "app.toml")
+	errs := make(chan error, 10)
+	stop, err := forum.WatchConfig(app, file, func(err error) { errs <- err })
+	require.NoError(t, err)
+	defer stop()
+
+	require.NoError(t, os.WriteFile(file, []byte(`curse_words = "bad|bloodmagic"`), 0o600))
+	height := int64(0)
+	require.Eventually(t, func() bool {
+		height++
+		finalizeAndCommit(t, app, height)
+		return len(voteExtensionWords(t, app)) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, []string{"bad", "bloodmagic"}, voteExtensionWords(t, app))
+	require.Empty(t, errs)
+}
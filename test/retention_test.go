@@ -0,0 +1,92 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/stretchr/testify/require"
+
+	forum "github.com/alijnmerchant21/forum-updated/abci"
+)
+
+// commitBlocks commits empty blocks up to height and returns the RetainHeight
+// of each of them
+func commitBlocks(t *testing.T, app *forum.ForumApp, height int64) map[int64]int64 {
+	retain := make(map[int64]int64)
+	for h := int64(1); h <= height; h++ {
+		_, err := app.FinalizeBlock(context.Background(), &abci.RequestFinalizeBlock{Height: h})
+		require.NoError(t, err)
+		resp, err := app.Commit(context.Background(), &abci.RequestCommit{})
+		require.NoError(t, err)
+		retain[h] = resp.RetainHeight
+	}
+	return retain
+}
+
+func TestRetainHeight(t *testing.T) {
+	tests := []struct {
+		name      string
+		retention string
+		expected  map[int64]int64
+	}{
+		{
+			name:      "keep everything",
+			retention: ``,
+			expected:  map[int64]int64{1: 0, 5: 0, 9: 0},
+		},
+		{
+			name:      "keep recent",
+			retention: "keep_recent = 3",
+			expected:  map[int64]int64{1: 0, 3: 0, 4: 2, 5: 3, 9: 7},
+		},
+		{
+			name:      "keep every",
+			retention: "keep_recent = 3\nkeep_every = 4",
+			expected:  map[int64]int64{5: 0, 6: 4, 9: 4, 10: 8},
+		},
+		{
+			name:      "never below the latest snapshot",
+			retention: "keep_recent = 2\nsnapshot_interval = 4",
+			expected:  map[int64]int64{3: 2, 5: 4, 7: 4, 8: 7, 9: 8},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newApp(t, withConfig("curse_words = \"bad\"\n\n[retention]\n"+tt.retention+"\n"))
+			initChain(t, app, `{}`)
+			retain := commitBlocks(t, app, 10)
+			for height, expected := range tt.expected {
+				require.Equal(t, expected, retain[height], "retain height after block %d", height)
+			}
+		})
+	}
+}
+
+func TestHistoryFollowsRetainHeight(t *testing.T) {
+	app := newApp(t, withConfig(`
+curse_words = "bad"
+
+[history]
+pruning = "nothing"
+
+[retention]
+keep_recent = 2
+`))
+	initChain(t, app, `{}`)
+	// The history is pruned along with the blocks every 10 blocks
+	commitBlocks(t, app, 10)
+
+	resp, err := queryAt(app, forum.QueryPathLeaderboard, "", 8)
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeHeightUnavailable, resp.Code)
+	resp, err = queryAt(app, forum.QueryPathLeaderboard, "", 9)
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeOK, resp.Code)
+}
+
+func TestInvalidRetention(t *testing.T) {
+	cfg := forum.DefaultConfig()
+	cfg.Retention.KeepRecent = -1
+	require.Error(t, cfg.Validate())
+}
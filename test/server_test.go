@@ -0,0 +1,70 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+
+	abcicli "github.com/cometbft/cometbft/abci/client"
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/cometbft/cometbft/types"
+	"github.com/stretchr/testify/require"
+
+	forum "github.com/alijnmerchant21/forum-updated/abci"
+)
+
+func freeTCPAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	return fmt.Sprintf("tcp://%s", l.Addr())
+}
+
+func TestABCIServer(t *testing.T) {
+	for transport, addr := range map[string]string{
+		forum.TransportSocket: "unix://" + filepath.Join(t.TempDir(), "forum.sock"),
+		forum.TransportGRPC:   freeTCPAddr(t),
+	} {
+		t.Run(transport, func(t *testing.T) {
+			app := newApp(t)
+			server, err := forum.NewServer(app, transport, addr)
+			require.NoError(t, err)
+			require.NoError(t, server.Start())
+			t.Cleanup(func() { require.NoError(t, server.Stop()) })
+
+			client, err := abcicli.NewClient(addr, transport, true)
+			require.NoError(t, err)
+			require.NoError(t, client.Start())
+			t.Cleanup(func() { require.NoError(t, client.Stop()) })
+
+			ctx := context.Background()
+			alice := newAccount("alice")
+			params := types.DefaultConsensusParams().ToProto()
+			_, err = client.InitChain(ctx, &abci.RequestInitChain{
+				ConsensusParams: &params,
+				AppStateBytes:   []byte(`{` + genesisUsers(alice) + `}`),
+			})
+			require.NoError(t, err)
+			post := alice.post(t, "hello")
+			check, err := client.CheckTx(ctx, &abci.RequestCheckTx{Tx: post})
+			require.NoError(t, err)
+			require.Equal(t, forum.CodeTypeOK, check.Code)
+			block, err := client.FinalizeBlock(ctx, &abci.RequestFinalizeBlock{Height: 1, Txs: [][]byte{post}})
+			require.NoError(t, err)
+			require.Equal(t, forum.CodeTypeOK, block.TxResults[0].Code)
+			_, err = client.Commit(ctx, &abci.RequestCommit{})
+			require.NoError(t, err)
+
+			info, err := client.Info(ctx, &abci.RequestInfo{})
+			require.NoError(t, err)
+			require.Equal(t, int64(1), info.LastBlockHeight)
+		})
+	}
+}
+
+func TestUnknownTransport(t *testing.T) {
+	_, err := forum.NewServer(newApp(t), "http", "tcp://127.0.0.1:26658")
+	require.Error(t, err)
+}
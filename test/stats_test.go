@@ -0,0 +1,98 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/stretchr/testify/require"
+
+	forum "github.com/alijnmerchant21/forum-updated/abci"
+	"github.com/alijnmerchant21/forum-updated/model"
+)
+
+func TestUserStats(t *testing.T) {
+	app := newApp(t)
+	ctx := context.Background()
+	alice, bob, mallory := newAccount("alice"), newAccount("bob"), newAccount("mallory")
+	initChain(t, app, `{`+genesisUsers(alice, bob, mallory)+`}`)
+
+	finalizeAndCommit(t, app, 1, alice.post(t, "one"), bob.post(t, "hi"))
+	banTx, err := json.Marshal(model.BanTx{UserName: "mallory"})
+	require.NoError(t, err)
+	results := finalizeAndCommit(t, app, 2,
+		banTx,
+		alice.post(t, "two"),
+		alice.tx(t, model.TxTypeEdit, model.EditTx{MessageID: 1, Message: "one, edited"}),
+		bob.tx(t, model.TxTypeDelete, model.DeleteTx{MessageID: 2}),
+		// bob can't edit the messages of alice
+		bob.tx(t, model.TxTypeEdit, model.EditTx{MessageID: 1, Message: "mine now"}),
+	)
+	require.Equal(t, forum.CodeTypeOK, results[2].Code)
+	require.Equal(t, forum.EventTypeEdit, results[2].Events[0].Type)
+	require.Equal(t, forum.CodeTypeOK, results[3].Code)
+	require.Equal(t, forum.EventTypeDelete, results[3].Events[0].Type)
+	require.Equal(t, forum.CodeTypeUnknownMessage, results[4].Code)
+
+	u := queryUser(t, app, "alice")
+	require.Equal(t, model.UserStats{
+		Name: "alice", Posts: 2, FirstPostHeight: 1, LastPostHeight: 2, Edits: 1,
+	}, u.Stats())
+	require.Equal(t, int64(1), queryUser(t, app, "bob").Deletions)
+	require.Equal(t, int64(1), queryUser(t, app, "mallory").Strikes)
+
+	query, err := app.Query(ctx, &abci.RequestQuery{Data: []byte("alice")})
+	require.NoError(t, err)
+	var messages []model.Message
+	require.NoError(t, json.Unmarshal(query.Value, &messages))
+	require.Equal(t, "one, edited", messages[0].Message)
+	require.True(t, messages[0].Edited)
+
+	query, err = app.Query(ctx, &abci.RequestQuery{Path: forum.QueryPathLeaderboard})
+	require.NoError(t, err)
+	var leaderboard []model.UserStats
+	require.NoError(t, json.Unmarshal(query.Value, &leaderboard))
+	require.Len(t, leaderboard, 2)
+	require.Equal(t, "alice", leaderboard[0].Name)
+	require.Equal(t, "bob", leaderboard[1].Name)
+
+	query, err = app.Query(ctx, &abci.RequestQuery{Path: forum.QueryPathLeaderboard, Data: []byte("1")})
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(query.Value, &leaderboard))
+	require.Len(t, leaderboard, 1)
+}
+
+func TestLeaderboardIndex(t *testing.T) {
+	db := newInMemoryDB(t)
+	post := func(name string, height int64) {
+		u, err := model.GetUser(db, name)
+		if err == model.ErrNotFound {
+			u, err = &model.User{Name: name}, nil
+		}
+		require.NoError(t, err)
+		require.NoError(t, model.RecordPost(db, u, height))
+		require.NoError(t, model.SetUser(db, u))
+	}
+	names := func(limit int) []string {
+		stats, err := model.Leaderboard(db, limit)
+		require.NoError(t, err)
+		var names []string
+		for _, s := range stats {
+			names = append(names, s.Name)
+		}
+		return names
+	}
+
+	post("carol", 1)
+	post("alice", 1)
+	post("bob", 2)
+	// Most recent post first, then by name
+	require.Equal(t, []string{"bob", "alice", "carol"}, names(10))
+
+	// carol moves up, once
+	post("carol", 3)
+	require.Equal(t, []string{"carol", "bob", "alice"}, names(10))
+	require.Equal(t, []string{"carol"}, names(1))
+	require.Empty(t, names(0))
+}
@@ -0,0 +1,94 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/stretchr/testify/require"
+
+	forum "github.com/alijnmerchant21/forum-updated/abci"
+	"github.com/alijnmerchant21/forum-updated/model"
+)
+
+var backends = []string{model.BackendBadger, model.BackendGoLevelDB, model.BackendMemory}
+
+func TestStoreBackends(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(backend, func(t *testing.T) {
+			store, err := model.OpenStore(backend, t.TempDir())
+			require.NoError(t, err)
+			defer store.Close()
+
+			value, err := store.Get([]byte("user/alice"))
+			require.NoError(t, err)
+			require.Nil(t, value)
+
+			require.NoError(t, store.Set([]byte("user/bob"), []byte("b")))
+			require.NoError(t, store.Set([]byte("user/alice"), []byte("a")))
+			require.NoError(t, store.Set([]byte("val/x"), []byte("x")))
+			value, err = store.Get([]byte("user/alice"))
+			require.NoError(t, err)
+			require.Equal(t, []byte("a"), value)
+
+			snapshot, err := store.Snapshot()
+			if errors.Is(err, model.ErrSnapshotsUnsupported) {
+				snapshot = nil
+			} else {
+				require.NoError(t, err)
+				defer snapshot.Release()
+			}
+
+			batch := store.NewBatch()
+			require.NoError(t, batch.Delete([]byte("user/bob")))
+			require.NoError(t, batch.Set([]byte("user/carol"), []byte("c")))
+			require.NoError(t, batch.Write())
+			batch.Discard()
+
+			iterate := func(r interface {
+				Iterate([]byte, func(key, value []byte) error) error
+			}) []string {
+				var keys []string
+				require.NoError(t, r.Iterate([]byte("user/"), func(key, _ []byte) error {
+					keys = append(keys, string(key))
+					return nil
+				}))
+				return keys
+			}
+			require.Equal(t, []string{"user/alice", "user/carol"}, iterate(store))
+
+			// The snapshot still sees the data from before the batch
+			if snapshot != nil {
+				require.Equal(t, []string{"user/alice", "user/bob"}, iterate(snapshot))
+				value, err = snapshot.Get([]byte("user/carol"))
+				require.NoError(t, err)
+				require.Nil(t, value)
+			}
+
+			require.NoError(t, store.Delete([]byte("user/alice")))
+			require.Equal(t, []string{"user/carol"}, iterate(store))
+		})
+	}
+}
+
+func TestAppOnEveryBackend(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(backend, func(t *testing.T) {
+			app := newApp(t, withConfig(`db_backend = "`+backend+`"`))
+			alice := newAccount("alice")
+			initChain(t, app, `{`+genesisUsers(alice)+`}`)
+			results := finalizeAndCommit(t, app, 1, alice.post(t, "hello"))
+			require.Equal(t, forum.CodeTypeOK, results[0].Code)
+
+			query, err := app.Query(context.Background(), &abci.RequestQuery{Path: forum.QueryPathUser, Data: []byte("alice")})
+			require.NoError(t, err)
+			require.Contains(t, string(query.Value), `"NumMessages":1`)
+		})
+	}
+}
+
+func TestUnknownBackend(t *testing.T) {
+	_, err := model.OpenStore("nosuchdb", t.TempDir())
+	require.Error(t, err)
+}
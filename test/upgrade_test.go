@@ -0,0 +1,123 @@
+package test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/stretchr/testify/require"
+
+	forum "github.com/alijnmerchant21/forum-updated/abci"
+	"github.com/alijnmerchant21/forum-updated/model"
+)
+
+var registerUpgrade sync.Once
+
+// The tests can upgrade to version 2, which adds a word to the word list
+func registerTestUpgrade() {
+	registerUpgrade.Do(func() {
+		forum.RegisterUpgradeHandler(2, func(store model.KVStore, plan model.UpgradePlan) error {
+			return model.UpdateWordList(store, []string{plan.Name}, nil)
+		})
+	})
+}
+
+func newUpgradeChain(t *testing.T) (*forum.ForumApp, *testAccount, *testAccount, *testAccount) {
+	registerTestUpgrade()
+	alice, bob, carol := newAccount("alice"), newAccount("bob"), newAccount("carol")
+	app := newApp(t, withGenesis(`{
+		"params": {"upgrade": {"quorum": 2, "voting_period": 5}},
+		`+genesisUsers(alice, bob, carol)+`,
+		"moderators": ["bob", "carol"]
+	}`))
+	return app, alice, bob, carol
+}
+
+func propose(t *testing.T, a *testAccount, plan model.UpgradePlan) []byte {
+	return a.tx(t, model.TxTypeProposeUpgrade, model.ProposeUpgradeTx{Plan: plan})
+}
+
+func approve(t *testing.T, a *testAccount, id uint64) []byte {
+	return a.tx(t, model.TxTypeApproveUpgrade, model.ApproveUpgradeTx{ProposalID: id})
+}
+
+func TestScheduledUpgrade(t *testing.T) {
+	app, alice, bob, carol := newUpgradeChain(t)
+	ctx := context.Background()
+
+	resp, err := app.CheckTx(ctx, &abci.RequestCheckTx{Tx: propose(t, alice, model.UpgradePlan{Name: "v2", Version: 2, Height: 4})})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeUnauthorized, resp.Code)
+
+	results := finalizeAndCommit(t, app, 1, propose(t, bob, model.UpgradePlan{Name: "v2", Version: 2, Height: 4}))
+	require.Equal(t, forum.CodeTypeOK, results[0].Code)
+	require.Equal(t, forum.EventTypeUpgradeProposal, results[0].Events[0].Type)
+
+	results = finalizeAndCommit(t, app, 2, approve(t, carol, 1), approve(t, bob, 1))
+	require.Equal(t, forum.CodeTypeOK, results[0].Code)
+	require.Equal(t, forum.EventTypeUpgradeScheduled, results[0].Events[1].Type)
+	require.Equal(t, forum.CodeTypeInvalidUpgrade, results[1].Code)
+	finalizeAndCommit(t, app, 3)
+
+	info, err := app.Info(ctx, &abci.RequestInfo{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), info.AppVersion)
+
+	block, err := app.FinalizeBlock(ctx, &abci.RequestFinalizeBlock{Height: 4})
+	require.NoError(t, err)
+	require.Equal(t, forum.EventTypeUpgrade, block.Events[0].Type)
+	require.Equal(t, uint64(2), block.ConsensusParamUpdates.Version.App)
+	_, err = app.Commit(ctx, &abci.RequestCommit{})
+	require.NoError(t, err)
+
+	info, err = app.Info(ctx, &abci.RequestInfo{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), info.AppVersion)
+	exported, err := app.Export(0)
+	require.NoError(t, err)
+	require.Equal(t, []string{"v2"}, exported.WordList)
+
+	// The chain runs version 2 now
+	resp, err = app.CheckTx(ctx, &abci.RequestCheckTx{Tx: propose(t, bob, model.UpgradePlan{Name: "v2", Version: 2, Height: 10})})
+	require.NoError(t, err)
+	require.Equal(t, forum.CodeTypeInvalidUpgrade, resp.Code)
+}
+
+func TestUpgradeNeeded(t *testing.T) {
+	app, _, bob, carol := newUpgradeChain(t)
+
+	finalizeAndCommit(t, app, 1,
+		propose(t, bob, model.UpgradePlan{Name: "v9", Version: 9, Height: 3, Info: "https://example.com/forum/v9"}),
+		approve(t, carol, 1),
+	)
+	finalizeAndCommit(t, app, 2)
+
+	// This binary can't run version 9, so the node stops before executing the block
+	_, err := app.FinalizeBlock(context.Background(), &abci.RequestFinalizeBlock{Height: 3})
+	require.ErrorIs(t, err, forum.ErrUpgradeNeeded)
+	info, err := app.Info(context.Background(), &abci.RequestInfo{})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), info.LastBlockHeight)
+	require.Equal(t, uint64(1), info.AppVersion)
+}
+
+func TestUpgradeProposalExpires(t *testing.T) {
+	app, _, bob, carol := newUpgradeChain(t)
+
+	finalizeAndCommit(t, app, 1, propose(t, bob, model.UpgradePlan{Name: "v2", Version: 2, Height: 100}))
+	for height := int64(2); height <= 6; height++ {
+		finalizeAndCommit(t, app, height)
+	}
+	results := finalizeAndCommit(t, app, 7, approve(t, carol, 1))
+	require.Equal(t, forum.CodeTypeInvalidUpgrade, results[0].Code)
+}
+
+func TestUpgradesDisabled(t *testing.T) {
+	app := newApp(t)
+	bob := newAccount("bob")
+	initChain(t, app, `{`+genesisUsers(bob)+`, "moderators": ["bob"]}`)
+
+	results := finalizeAndCommit(t, app, 1, propose(t, bob, model.UpgradePlan{Name: "v2", Version: 2, Height: 10}))
+	require.Equal(t, forum.CodeTypeInvalidUpgrade, results[0].Code)
+}